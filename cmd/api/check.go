@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/georgegg/go-patron-realworld-example-app/internal/admin"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/analytics"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/buildinfo"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/config"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/db"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/i18n"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/jwks"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/middleware"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/ratelimit"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/secrets"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/server"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/signing"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/status"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tenant"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tracing"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/wordfilter"
+)
+
+// checkTimeout is the time the check command waits for the dependencies.
+const checkTimeout = 10 * time.Second
+
+// preflight is a named validation of the check command. A nil func marks a skipped validation.
+type preflight struct {
+	name string
+	run  func() error
+}
+
+// check validates the configuration of the serve command and checks the dependencies, as a deploy preflight.
+// It prints a report and fails if any validation fails.
+func check(build buildinfo.Info, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var (
+		configFile = fs.String("config", os.Getenv("REALWORLD_CONFIG_FILE"), "path of the dynamic configuration file")
+		requireJWT = fs.Bool("require-jwt-keys", false, "fail if no JWT signing key directory is configured")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return runPreflights(os.Stdout, build, preflights(*configFile, *requireJWT))
+}
+
+func preflights(configFile string, requireJWT bool) []preflight {
+	pp := []preflight{
+		{name: "tracing backend", run: func() error {
+			_, err := tracing.BackendFromEnv()
+			return err
+		}},
+		{name: "access log", run: func() error {
+			_, _, err := middleware.NewAccessLogFromEnv()
+			return err
+		}},
+		{name: "load shedder", run: func() error {
+			_, err := newShedder()
+			return err
+		}},
+		{name: "security headers", run: func() error {
+			_, err := middleware.NewSecurityHeaders()
+			return err
+		}},
+		{name: "management port", run: func() error {
+			_, _, err := server.ManagementPortsFromEnv()
+			return err
+		}},
+		{name: "api server", run: func() error {
+			ports, _, err := server.ManagementPortsFromEnv()
+			if err != nil {
+				return err
+			}
+			_, _, err = server.NewFromEnv(ports.API)
+			return err
+		}},
+		{name: "admin server", run: func() error {
+			_, separateAdmin, err := server.NewAdminFromEnv()
+			if err != nil {
+				return err
+			}
+			ports, _, err := server.ManagementPortsFromEnv()
+			if err != nil {
+				return err
+			}
+			_, separateAPI, err := server.NewFromEnv(ports.API)
+			if err != nil {
+				return err
+			}
+			return checkAdminExposure(separateAdmin, separateAPI)
+		}},
+		{name: "rate limit classes", run: func() error {
+			_, err := ratelimit.NewClassesFromEnv()
+			return err
+		}},
+		{name: "tenants", run: func() error {
+			_, _, err := tenant.NewRegistryFromEnv()
+			return err
+		}},
+		{name: "admin token", run: func() error {
+			_, _, err := admin.NewTokenAuthenticatorFromEnv()
+			return err
+		}},
+		{name: "secrets provider", run: func() error {
+			_, err := secrets.NewProviderFromEnv()
+			return err
+		}},
+		{name: "db pool", run: func() error {
+			_, err := db.PoolConfigFromEnv()
+			return err
+		}},
+		{name: "request signing keys", run: func() error {
+			sp, err := secrets.NewProviderFromEnv()
+			if err != nil {
+				return err
+			}
+			_, _, err = signing.NewVerifierFromEnv(context.Background(), sp)
+			return err
+		}},
+		{name: "analytics sink", run: func() error {
+			_, _, err := analytics.NewClickHouseSinkFromEnv()
+			return err
+		}},
+	}
+
+	var loadConfig, loadCatalogs, loadWords, loadKeys func() error
+	if configFile != "" {
+		loadConfig = func() error {
+			_, err := config.LoadFile(configFile)
+			return err
+		}
+	}
+	if dir, ok := os.LookupEnv("REALWORLD_I18N_DIR"); ok {
+		loadCatalogs = func() error { return i18n.NewCatalog().LoadDir(dir) }
+	}
+	if dir, ok := os.LookupEnv("REALWORLD_WORDFILTER_DIR"); ok {
+		loadWords = func() error { return wordfilter.New().LoadDir(dir) }
+	}
+	if dir, ok := os.LookupEnv("REALWORLD_JWT_KEY_DIR"); ok {
+		loadKeys = func() error {
+			_, err := jwks.NewKeySet(dir)
+			return err
+		}
+	} else if requireJWT {
+		loadKeys = func() error { return fmt.Errorf("REALWORLD_JWT_KEY_DIR is not set") }
+	}
+
+	return append(pp,
+		preflight{name: "dynamic configuration", run: loadConfig},
+		preflight{name: "message catalogs", run: loadCatalogs},
+		preflight{name: "word filter dictionaries", run: loadWords},
+		preflight{name: "jwt signing keys", run: loadKeys},
+	)
+}
+
+func runPreflights(w io.Writer, build buildinfo.Info, pp []preflight) error {
+	fmt.Fprintf(w, "%s %s\n\n", serviceName, build)
+
+	failed := 0
+	for _, p := range pp {
+		if p.run == nil {
+			fmt.Fprintf(w, "skip  %s: not configured\n", p.name)
+			continue
+		}
+		if err := p.run(); err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL  %s: %v\n", p.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "ok    %s\n", p.name)
+	}
+
+	deps, err := newDependencies(build)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	for _, d := range deps.Report(ctx).Dependencies {
+		if d.Status == status.Down {
+			failed++
+			fmt.Fprintf(w, "FAIL  dependency %s: %s\n", d.Name, d.Error)
+			continue
+		}
+		fmt.Fprintf(w, "ok    dependency %s %s (%.1fms)\n", d.Name, d.Version, d.LatencyMS)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	fmt.Fprintln(w, "\nall checks passed")
+	return nil
+}
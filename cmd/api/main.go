@@ -1,51 +1,119 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"os"
-	"runtime"
+	"sort"
 
 	"github.com/beatlabs/patron"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/analytics"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/buildinfo"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/logging"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/status"
 )
 
+const serviceName = "go-patron-realworld-example-app"
+
+// The build info is injected at compile time, e.g.
+// go build -ldflags "-X main.theVersion=1.0.0 -X main.theCommit=$(git rev-parse HEAD) -X main.theBranch=$(git rev-parse --abbrev-ref HEAD) -X main.theBuildDate=$(date -u +%FT%TZ)"
 var (
-	theVersion = "dev"
+	theVersion   = "dev"
+	theCommit    = ""
+	theBranch    = ""
+	theBuildDate = ""
 )
 
+// command is a subcommand of the binary, parsing its own flags from args.
+type command struct {
+	usage string
+	run   func(build buildinfo.Info, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":     {usage: "run the service (default)", run: serve},
+	"check":     {usage: "validate the configuration and check the dependencies", run: check},
+	"mockserve": {usage: "serve the API from in-memory fixtures, for frontends and contract tests", run: mockserve},
+	"version":   {usage: "print the build info", run: printVersion},
+}
+
 func main() {
-	if err := run(theVersion); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v", err)
+	if err := run(buildinfo.New(theVersion, theCommit, theBranch, theBuildDate), os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run(version string) error {
-	var (
-		showVersion = flag.Bool("v", false, "print version number")
-	)
-	flag.Parse()
+// run dispatches to the subcommand named by the first argument. Without a subcommand, or if the
+// first argument is a flag, the service is served, so that the binary is invoked as before.
+func run(build buildinfo.Info, args []string) error {
+	name := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return nil
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd.run(build, args)
+}
 
-	if *showVersion {
-		fmt.Printf("%s %s (runtime: %s)\n", os.Args[0], version, runtime.Version())
-		os.Exit(0)
+func usage() {
+	nn := make([]string, 0, len(commands))
+	for n := range commands {
+		nn = append(nn, n)
 	}
+	sort.Strings(nn)
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, n := range nn {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", n, commands[n].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun %s <command> -h for the flags of a command.\n", os.Args[0])
+}
 
-	const serviceName = "go-patron-realworld-example-app"
+func printVersion(build buildinfo.Info, _ []string) error {
+	fmt.Printf("%s %s\n", os.Args[0], build)
+	return nil
+}
 
+// setupLogging sets up the patron logger with the level of PATRON_LOG_LEVEL, shared by all commands.
+func setupLogging(version string) error {
 	err := patron.Setup(serviceName, version)
 	if err != nil {
 		return fmt.Errorf("failed to set up logging: %v", err)
 	}
 
-	srv, err := patron.New(serviceName, version)
+	lvl, ok := os.LookupEnv("PATRON_LOG_LEVEL")
+	if !ok {
+		lvl = string(log.InfoLevel)
+	}
+	err = logging.Setup(serviceName, version, log.Level(lvl))
 	if err != nil {
-		return fmt.Errorf("failed to create service %v", err)
+		return fmt.Errorf("failed to set up logging: %v", err)
 	}
+	return nil
+}
 
-	err = srv.Run()
+// newDependencies creates the status registry with the checkers of the dependencies of the service,
+// shared by the status route and the check command.
+func newDependencies(build buildinfo.Info) (*status.Registry, error) {
+	deps, err := status.NewRegistry(build)
 	if err != nil {
-		return fmt.Errorf("failed to run service %v", err)
+		return nil, fmt.Errorf("failed to create status registry: %v", err)
 	}
-	return nil
+	sink, analyticsEnabled, err := analytics.NewClickHouseSinkFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analytics sink: %v", err)
+	}
+	if analyticsEnabled {
+		if err := deps.Add("clickhouse", sink); err != nil {
+			return nil, fmt.Errorf("failed to register dependency: %v", err)
+		}
+	}
+	return deps, nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/buildinfo"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/config"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/middleware"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/mock"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/server"
+)
+
+// mockserve serves the RealWorld API from in-memory fixtures until it is stopped.
+func mockserve(build buildinfo.Info, args []string) error {
+	fs := flag.NewFlagSet("mockserve", flag.ExitOnError)
+	var (
+		addr      = fs.String("addr", "localhost:8080", "address to listen on")
+		latency   = fs.Duration("latency", 0, "latency added to every API request")
+		errorRate = fs.Float64("error-rate", 0, "percentage of API requests failed with a 500")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	err := setupLogging(build.Version)
+	if err != nil {
+		return err
+	}
+
+	routes, err := mock.Routes(mock.NewStore())
+	if err != nil {
+		return fmt.Errorf("failed to create mock routes: %v", err)
+	}
+
+	// latency and errors are independent, so they are injected by separate injectors,
+	// since only the first matching rule of an injector applies.
+	var rules []middleware.FaultRule
+	if *latency > 0 {
+		rules = append(rules, middleware.FaultRule{PathPrefix: "/api/", Percent: 100, Latency: config.Duration(*latency)})
+	}
+	if *errorRate > 0 {
+		rules = append(rules, middleware.FaultRule{PathPrefix: "/api/", Percent: *errorRate, Status: http.StatusInternalServerError})
+	}
+	var middlewares []patronhttp.MiddlewareFunc
+	for _, r := range rules {
+		faults := middleware.NewFaultInjector()
+		if err := faults.SetRules([]middleware.FaultRule{r}); err != nil {
+			return fmt.Errorf("invalid fault flags: %v", err)
+		}
+		middlewares = append(middlewares, faults.Middleware())
+	}
+
+	oo := []server.OptionFunc{server.Routes(routes)}
+	if len(middlewares) > 0 {
+		oo = append(oo, server.Middlewares(middlewares...))
+	}
+	srv, err := server.New([]string{*addr}, oo...)
+	if err != nil {
+		return fmt.Errorf("failed to create mock server: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	log.Infof("serving the mock API on %s with latency %s and error rate %v%%", *addr, *latency, *errorRate)
+	return srv.Run(ctx)
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/admin"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/analytics"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apikey"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/buildinfo"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/config"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/i18n"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/jwks"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/logging"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/middleware"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/ratelimit"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/scheduler"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/secrets"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/server"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/signing"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/status"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/suggest"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tenant"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tracing"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/unfurl"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/wordfilter"
+)
+
+// the rate limit classes of the routes, in requests per second per client.
+const (
+	rateClassUnfurl = "unfurl"
+	unfurlRate      = 1
+	unfurlBurst     = 10
+
+	rateClassEvents = "events"
+	eventsRate      = 5
+	eventsBurst     = 20
+
+	rateClassSuggest = "suggest"
+	suggestRate      = 10
+	suggestBurst     = 20
+)
+
+// responseCacheEntries is the maximum number of responses kept by the response cache.
+const responseCacheEntries = 10000
+
+// internalPrefix is the prefix of the routes of the internal services.
+const internalPrefix = "/internal"
+
+// serve runs the service until it is stopped.
+func serve(build buildinfo.Info, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var (
+		showVersion    = fs.Bool("v", false, "print version number")
+		configFile     = fs.String("config", os.Getenv("REALWORLD_CONFIG_FILE"), "path of the dynamic configuration file")
+		validateConfig = fs.Bool("validate-config", false, "validate the configuration and exit, like the check command")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *showVersion {
+		return printVersion(build, nil)
+	}
+	if *validateConfig {
+		return runPreflights(os.Stdout, build, preflights(*configFile, false))
+	}
+
+	version := build.Version
+
+	err := setupLogging(version)
+	if err != nil {
+		return err
+	}
+
+	var (
+		oo          []patron.OptionFunc
+		cc          []patron.Component
+		routes      []patronhttp.Route
+		adminRoutes []patronhttp.Route
+		mgmtRoutes  []patronhttp.Route
+		middlewares []patronhttp.MiddlewareFunc
+	)
+
+	// the load shedder comes before the other middlewares, so that rejected requests cost as little as possible.
+	shedder, err := newShedder()
+	if err != nil {
+		return fmt.Errorf("failed to create load shedder: %v", err)
+	}
+	middlewares = append(middlewares, shedder.Middleware())
+
+	secHeaders, err := middleware.NewSecurityHeaders()
+	if err != nil {
+		return fmt.Errorf("failed to create security headers middleware: %v", err)
+	}
+	if os.Getenv("REALWORLD_HTTPS_REDIRECT") == "true" {
+		middlewares = append(middlewares, middleware.NewHTTPSRedirect())
+	}
+	middlewares = append(middlewares, secHeaders)
+
+	catalog := i18n.NewCatalog()
+	if dir, ok := os.LookupEnv("REALWORLD_I18N_DIR"); ok {
+		if err := catalog.LoadDir(dir); err != nil {
+			return fmt.Errorf("failed to load message catalogs: %v", err)
+		}
+	}
+	middlewares = append(middlewares, middleware.NewI18n(catalog))
+
+	tenants, multiTenant, err := tenant.NewRegistryFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to set up tenants: %v", err)
+	}
+	if multiTenant {
+		middlewares = append(middlewares, middleware.NewTenant(tenants))
+	}
+
+	sp, err := secrets.NewProviderFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to create secrets provider: %v", err)
+	}
+	verifier, signingEnabled, err := signing.NewVerifierFromEnv(context.Background(), sp)
+	if err != nil {
+		return fmt.Errorf("failed to set up request signing: %v", err)
+	}
+	if signingEnabled {
+		middlewares = append(middlewares, signing.Middleware(verifier))
+	}
+	// the users of the tokens and the API keys are added to the context ahead of the router, so that
+	// the deprecated routes can count their consumers before authenticating them.
+	var keys *jwks.KeySet
+	if dir, ok := os.LookupEnv("REALWORLD_JWT_KEY_DIR"); ok {
+		keys, err = jwks.NewKeySet(dir)
+		if err != nil {
+			return fmt.Errorf("failed to load signing keys: %v", err)
+		}
+		middlewares = append(middlewares, jwks.Middleware(keys))
+	}
+	apiKeys := apikey.NewMemoryStore()
+	middlewares = append(middlewares, apikey.Middleware(apiKeys))
+
+	adminAuth, adminEnabled, err := admin.NewTokenAuthenticatorFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to set up admin authentication: %v", err)
+	}
+
+	rateClasses, err := ratelimit.NewClassesFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to create rate limit classes: %v", err)
+	}
+	if err := rateClasses.Add(rateClassUnfurl, unfurlRate, unfurlBurst); err != nil {
+		return fmt.Errorf("failed to create rate limit classes: %v", err)
+	}
+	if err := rateClasses.Add(rateClassEvents, eventsRate, eventsBurst); err != nil {
+		return fmt.Errorf("failed to create rate limit classes: %v", err)
+	}
+	if err := rateClasses.Add(rateClassSuggest, suggestRate, suggestBurst); err != nil {
+		return fmt.Errorf("failed to create rate limit classes: %v", err)
+	}
+	jobs := []scheduler.Job{{
+		Name:     "rate-limit-prune",
+		Interval: time.Minute,
+		// the limiters are kept in the memory of each instance.
+		PerInstance: true,
+		Run: func(context.Context) error {
+			rateClasses.Prune()
+			return nil
+		},
+	}}
+
+	cacheCfg := config.DefaultDynamic().Cache
+	responses, err := middleware.NewResponseCache(time.Duration(cacheCfg.TTL), time.Duration(cacheCfg.StaleWhileRevalidate), responseCacheEntries)
+	if err != nil {
+		return fmt.Errorf("failed to create response cache: %v", err)
+	}
+
+	apiOpts := []route.RegistryOptionFunc{
+		route.RateLimit(rateClasses.Middleware),
+		route.Cache(responses),
+		route.Consumer(apikey.Consumer(route.DefaultConsumer)),
+	}
+	// the routes requiring authentication are served only with the keys verifying the user tokens.
+	var users *jwks.Authenticator
+	if keys != nil {
+		users, err = jwks.NewAuthenticator(keys)
+		if err != nil {
+			return fmt.Errorf("failed to create user authenticator: %v", err)
+		}
+		apiOpts = append(apiOpts, route.Authenticator(users))
+	}
+	api, err := route.NewRegistry(apiOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create route registry: %v", err)
+	}
+	// the internal routes are called by the internal services, authenticated by their request signature.
+	var internal *route.Registry
+	if signingEnabled {
+		internal, err = route.NewRegistry(route.Authenticator(verifier))
+		if err != nil {
+			return fmt.Errorf("failed to create internal route registry: %v", err)
+		}
+	}
+	buildinfo.Register(build)
+	err = api.Add(
+		buildinfo.Spec(build),
+		route.Spec{Method: http.MethodGet, Path: unfurl.Path, Processor: unfurl.Processor(unfurl.New()), RateClass: rateClassUnfurl},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register routes: %v", err)
+	}
+	// the suggestion indexes start empty, and are filled with Index.Add as tags and usernames are written.
+	if err := api.Add(suggest.Specs(suggest.NewIndex(), suggest.NewIndex(), rateClassSuggest)...); err != nil {
+		return fmt.Errorf("failed to register routes: %v", err)
+	}
+	if users != nil {
+		if err := api.Add(apikey.Specs(apiKeys, jwks.UserID)...); err != nil {
+			return fmt.Errorf("failed to register routes: %v", err)
+		}
+	}
+
+	sink, analyticsEnabled, err := analytics.NewClickHouseSinkFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to create analytics sink: %v", err)
+	}
+	if analyticsEnabled {
+		ingester, err := analytics.NewIngester(sink)
+		if err != nil {
+			return fmt.Errorf("failed to create analytics ingester: %v", err)
+		}
+		if err := api.Add(analytics.Spec(ingester, rateClassEvents)); err != nil {
+			return fmt.Errorf("failed to register routes: %v", err)
+		}
+		if signingEnabled {
+			// internal services sending server side events from a few addresses are authenticated
+			// by their signature instead of being rate limited per client.
+			ev := analytics.Spec(ingester, "")
+			ev.Auth = true
+			if err := internal.Add(ev); err != nil {
+				return fmt.Errorf("failed to register internal routes: %v", err)
+			}
+		}
+		cc = append(cc, ingester)
+	}
+	routes = append(routes, api.Apply(route.NewV1()).Routes()...)
+	if signingEnabled {
+		routes = append(routes, internal.Apply(route.NewGroup(internalPrefix)).Routes()...)
+	}
+	if keys != nil {
+		// the key set is served outside of the API, under the well-known prefix other services look it up by.
+		wellKnown, err := route.NewRegistry()
+		if err != nil {
+			return fmt.Errorf("failed to create well-known route registry: %v", err)
+		}
+		if err := wellKnown.Add(jwks.Spec(keys)); err != nil {
+			return fmt.Errorf("failed to register well-known routes: %v", err)
+		}
+		routes = append(routes, wellKnown.Apply(route.NewGroup(jwks.Prefix)).Routes()...)
+	}
+
+	sch, err := scheduler.New(scheduler.Jobs(jobs...))
+	if err != nil {
+		return fmt.Errorf("failed to create scheduler: %v", err)
+	}
+	cc = append(cc, sch)
+
+	deps, err := newDependencies(build)
+	if err != nil {
+		return err
+	}
+	mgmtRoutes = append(mgmtRoutes, status.Route(deps))
+
+	words := wordfilter.New()
+	if dir, ok := os.LookupEnv("REALWORLD_WORDFILTER_DIR"); ok {
+		if err := words.LoadDir(dir); err != nil {
+			return fmt.Errorf("failed to load word filter dictionaries: %v", err)
+		}
+	}
+
+	if adminEnabled {
+		adminRoutes = append(adminRoutes, admin.LogLevelRoutes(adminAuth)...)
+		adminRoutes = append(adminRoutes, admin.WordFilterRoutes(words, adminAuth)...)
+		middlewares = append(middlewares, middleware.NewDebug(adminAuth))
+	}
+
+	// fault injection is meant for staging, and is configured only by the admin API.
+	if os.Getenv("REALWORLD_FAULT_INJECTION") == "true" {
+		if !adminEnabled {
+			return fmt.Errorf("fault injection requires the admin API")
+		}
+		faults := middleware.NewFaultInjector()
+		adminRoutes = append(adminRoutes, admin.FaultRoutes(faults, adminAuth)...)
+		middlewares = append(middlewares, faults.Middleware())
+	}
+
+	if *configFile != "" {
+		reloader, err := config.NewReloader(*configFile)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		// without a log level in the file, the level set at startup by PATRON_LOG_LEVEL applies.
+		startLevel := logging.Level()
+		err = reloader.Subscribe(func(d config.Dynamic) error {
+			if d.LogLevel == "" {
+				return logging.SetLevel(startLevel)
+			}
+			return logging.SetLevel(log.Level(d.LogLevel))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply configuration: %v", err)
+		}
+		err = reloader.Subscribe(func(d config.Dynamic) error {
+			return shedder.SetLimits(d.RateLimit.MaxInFlight, d.RateLimit.MaxQueue)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply configuration: %v", err)
+		}
+		err = reloader.Subscribe(func(d config.Dynamic) error {
+			return responses.SetTTL(time.Duration(d.Cache.TTL), time.Duration(d.Cache.StaleWhileRevalidate))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply configuration: %v", err)
+		}
+		if keys != nil {
+			// reloading the configuration also picks up rotated signing keys.
+			err = reloader.Subscribe(func(config.Dynamic) error {
+				return keys.Load()
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply configuration: %v", err)
+			}
+		}
+		oo = append(oo, patron.SIGHUP(reloader.SIGHUP()))
+		if adminEnabled {
+			adminRoutes = append(adminRoutes, admin.ConfigReloadRoute(reloader, adminAuth))
+		}
+	}
+
+	// with a private admin server the admin, metrics and profiling routes are served only by it.
+	adminSrv, separateAdmin, err := server.NewAdminFromEnv(server.Routes(append(adminRoutes, server.ManagementRoutes()...)))
+	if err != nil {
+		return fmt.Errorf("failed to create admin server: %v", err)
+	}
+	if separateAdmin {
+		cc = append(cc, adminSrv)
+	} else {
+		routes = append(routes, adminRoutes...)
+	}
+
+	ports, separateMgmt, err := server.ManagementPortsFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to separate the management port: %v", err)
+	}
+	if separateMgmt {
+		// patron reads the port of its default component only from the env; the public port was resolved before.
+		if err := os.Setenv("PATRON_HTTP_DEFAULT_PORT", ports.Management); err != nil {
+			return fmt.Errorf("failed to set the management port: %v", err)
+		}
+	}
+
+	// the access log comes first, so that every request is logged, including the shed ones.
+	accessLog, accessLogEnabled, err := middleware.NewAccessLogFromEnv(middleware.AccessLogRoutes(append(routes, mgmtRoutes...)))
+	if err != nil {
+		return fmt.Errorf("failed to create access log: %v", err)
+	}
+	if accessLogEnabled {
+		middlewares = append([]patronhttp.MiddlewareFunc{accessLog.Middleware()}, middlewares...)
+	}
+
+	// with an API server the routes are served by it, and the default patron component
+	// only serves the management routes.
+	apiSrv, separateAPI, err := server.NewFromEnv(ports.API, server.Routes(routes), server.Middlewares(middlewares...))
+	if err != nil {
+		return fmt.Errorf("failed to create API server: %v", err)
+	}
+	if err := checkAdminExposure(separateAdmin, separateAPI); err != nil {
+		return err
+	}
+	if separateAPI {
+		cc = append(cc, apiSrv)
+		oo = append(oo, patron.Routes(mgmtRoutes))
+	} else {
+		oo = append(oo, patron.Routes(append(routes, mgmtRoutes...)), patron.Middlewares(middlewares...))
+	}
+	if len(cc) > 0 {
+		oo = append(oo, patron.Components(cc...))
+	}
+
+	srv, err := patron.New(serviceName, version, oo...)
+	if err != nil {
+		return fmt.Errorf("failed to create service %v", err)
+	}
+
+	tracer, err := tracing.Setup(serviceName, version, build.Commit)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %v", err)
+	}
+	defer func() {
+		if err := tracer.Close(); err != nil {
+			log.Errorf("failed to close tracer: %v", err)
+		}
+	}()
+
+	err = srv.Run()
+	if err != nil {
+		return fmt.Errorf("failed to run service %v", err)
+	}
+	return nil
+}
+
+// newShedder creates the load shedder with the default limits of the dynamic configuration.
+// REALWORLD_SHED_CPU_THRESHOLD enables CPU based shedding above the utilization, e.g. 0.9.
+func newShedder() (*middleware.Shedder, error) {
+	var oo []middleware.ShedderOptionFunc
+	if v, ok := os.LookupEnv("REALWORLD_SHED_CPU_THRESHOLD"); ok {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("env var for cpu threshold is not valid: %v", err)
+		}
+		oo = append(oo, middleware.CPUThreshold(threshold))
+	}
+	rl := config.DefaultDynamic().RateLimit
+	return middleware.NewShedder(rl.MaxInFlight, rl.MaxQueue, oo...)
+}
+
+// checkAdminExposure fails if the admin server is private but the API is served by the default patron
+// component, whose port also serves the metrics and profiling routes, which would then be public.
+func checkAdminExposure(separateAdmin, separateAPI bool) error {
+	if separateAdmin && !separateAPI {
+		return fmt.Errorf("a private admin server requires REALWORLD_API_ADDR or REALWORLD_MANAGEMENT_PORT, " +
+			"since the default patron port serves the metrics and profiling routes")
+	}
+	return nil
+}
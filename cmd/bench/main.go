@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/georgegg/go-patron-realworld-example-app/internal/bench"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		baseURL   = flag.String("url", "http://localhost:50000", "base URL of the service under test")
+		token     = flag.String("token", "", "JWT used by scenarios requiring authentication")
+		scenarios = flag.String("scenarios", "", "comma separated scenarios to run, all if empty")
+		workers   = flag.Int("workers", 50, "number of concurrent workers")
+		duration  = flag.Duration("duration", 0, "override the duration of the scenarios")
+		rate      = flag.Int("rate", 0, "override the request rate (req/s) of the scenarios")
+		method    = flag.String("method", "GET", "method of the custom scenario")
+		path      = flag.String("path", "", "path of a custom scenario to run instead of the default ones, e.g. /api/articles?limit=20")
+		body      = flag.String("body", "", "body of the custom scenario")
+	)
+	flag.Parse()
+
+	r, err := bench.NewRunner(*baseURL, *token, *workers)
+	if err != nil {
+		return fmt.Errorf("failed to create runner: %v", err)
+	}
+
+	selected := make(map[string]bool)
+	for _, s := range strings.Split(*scenarios, ",") {
+		if s != "" {
+			selected[s] = true
+		}
+	}
+
+	ss := bench.DefaultScenarios()
+	if *path != "" {
+		ss = []bench.Scenario{{
+			Name:     "custom",
+			Method:   strings.ToUpper(*method),
+			Path:     *path,
+			Body:     *body,
+			Auth:     *token != "",
+			Rate:     100,
+			Duration: 30 * time.Second,
+		}}
+	}
+
+	for _, s := range ss {
+		if len(selected) > 0 && !selected[s.Name] {
+			continue
+		}
+		if s.Auth && *token == "" {
+			fmt.Printf("skipping scenario %s: a token is required\n", s.Name)
+			continue
+		}
+		if *duration > 0 {
+			s.Duration = *duration
+		}
+		if *rate > 0 {
+			s.Rate = *rate
+		}
+
+		fmt.Printf("running scenario %s at %d req/s for %s\n", s.Name, s.Rate, s.Duration.Round(time.Second))
+		res, err := r.Run(context.Background(), s)
+		if err != nil {
+			return fmt.Errorf("failed to run scenario %s: %v", s.Name, err)
+		}
+		err = res.Report(os.Stdout)
+		if err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	}
+	return nil
+}
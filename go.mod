@@ -1,5 +1,27 @@
 module github.com/georgegg/go-patron-realworld-example-app
 
-go 1.12
+go 1.24
 
-require github.com/beatlabs/patron v0.23.0
+require (
+	github.com/beatlabs/patron v0.23.0
+	github.com/julienschmidt/httprouter v1.2.0
+	github.com/opentracing/opentracing-go v0.0.0-20180606204148-bd9c31933947
+	github.com/prometheus/client_golang v0.9.1
+	github.com/rs/zerolog v1.5.0
+	github.com/uber/jaeger-client-go v2.14.0+incompatible
+	github.com/uber/jaeger-lib v1.5.0
+	gopkg.in/russross/blackfriday.v2 v2.0.0
+)
+
+require (
+	github.com/beorn7/perks v0.0.0-20180321164747-3a771d992973 // indirect
+	github.com/codahale/hdrhistogram v0.0.0-20161010025455-3a0bb77429bd // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/google/uuid v1.1.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/pkg/errors v0.8.0 // indirect
+	github.com/prometheus/client_model v0.0.0-20190129233127-fd36f4220a90 // indirect
+	github.com/prometheus/common v0.2.0 // indirect
+	github.com/prometheus/procfs v0.0.0-20190129233650-316cf8ccfec5 // indirect
+	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
+)
@@ -0,0 +1,55 @@
+// Package admin contains the authentication and routes of the operational admin API.
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const bearer = "Bearer "
+
+// TokenAuthenticator authenticates admin requests carrying a static bearer token.
+// It implements the patron auth.Authenticator interface.
+type TokenAuthenticator struct {
+	token []byte
+}
+
+// NewTokenAuthenticator creates a new authenticator for the token.
+func NewTokenAuthenticator(token string) (*TokenAuthenticator, error) {
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+	return &TokenAuthenticator{token: []byte(token)}, nil
+}
+
+// NewTokenAuthenticatorFromEnv creates a new authenticator for the REALWORLD_ADMIN_TOKEN env var.
+// It returns false if the env var is not set, in which case the admin API should be disabled.
+func NewTokenAuthenticatorFromEnv() (*TokenAuthenticator, bool, error) {
+	token, ok := os.LookupEnv("REALWORLD_ADMIN_TOKEN")
+	if !ok {
+		return nil, false, nil
+	}
+	a, err := NewTokenAuthenticator(token)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "env var for admin token is not valid")
+	}
+	return a, true, nil
+}
+
+// Authenticate returns true if the request carries the admin token.
+func (a *TokenAuthenticator) Authenticate(req *http.Request) (bool, error) {
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, bearer) {
+		return false, nil
+	}
+	return a.Valid(strings.TrimPrefix(h, bearer)), nil
+}
+
+// Valid returns true if the token is the admin token.
+func (a *TokenAuthenticator) Valid(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), a.token) == 1
+}
@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/config"
+)
+
+// ConfigReloadRoute creates the POST /admin/config/reload route, which reloads the dynamic configuration.
+func ConfigReloadRoute(r *config.Reloader, a auth.Authenticator) patronhttp.Route {
+	pr := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		if err := r.Reload(); err != nil {
+			return nil, apierror.Internal(err.Error())
+		}
+		return nil, nil
+	}
+	return patronhttp.NewAuthPostRoute("/admin/config/reload", pr, true, a)
+}
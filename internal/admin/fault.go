@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/middleware"
+)
+
+const faultsPath = "/admin/faults"
+
+type faultRules struct {
+	Rules []middleware.FaultRule `json:"rules"`
+}
+
+// FaultRoutes creates the GET and PUT /admin/faults routes, which return and replace the fault injection rules.
+// An empty list of rules disables the injection. Changes are not persisted and are lost on restart.
+func FaultRoutes(f *middleware.FaultInjector, a auth.Authenticator) []patronhttp.Route {
+	get := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		return sync.NewResponse(faultRules{Rules: f.Rules()}), nil
+	}
+
+	put := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		var body faultRules
+		if err := req.Decode(&body); err != nil {
+			return nil, apierror.Validation("body is not valid")
+		}
+		if err := f.SetRules(body.Rules); err != nil {
+			return nil, apierror.Validation(err.Error())
+		}
+		return sync.NewResponse(faultRules{Rules: f.Rules()}), nil
+	}
+
+	return []patronhttp.Route{
+		patronhttp.NewAuthGetRoute(faultsPath, get, true, a),
+		patronhttp.NewAuthPutRoute(faultsPath, put, true, a),
+	}
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/logging"
+)
+
+const logLevelPath = "/admin/log/level"
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// LogLevelRoutes creates the GET and PUT /admin/log/level routes, which return and change the global log level.
+func LogLevelRoutes(a auth.Authenticator) []patronhttp.Route {
+	get := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		return sync.NewResponse(logLevel{Level: string(logging.Level())}), nil
+	}
+
+	put := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		var body logLevel
+		if err := req.Decode(&body); err != nil {
+			return nil, apierror.Validation("body is not valid")
+		}
+		if err := logging.SetLevel(log.Level(body.Level)); err != nil {
+			return nil, apierror.Validation(err.Error())
+		}
+		log.Infof("log level changed to %s", body.Level)
+		return sync.NewResponse(body), nil
+	}
+
+	return []patronhttp.Route{
+		patronhttp.NewAuthGetRoute(logLevelPath, get, true, a),
+		patronhttp.NewAuthPutRoute(logLevelPath, put, true, a),
+	}
+}
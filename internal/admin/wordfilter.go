@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/wordfilter"
+)
+
+const wordFilterPath = "/admin/wordfilter/:locale"
+
+type dictionary struct {
+	Words []string `json:"words"`
+}
+
+// WordFilterRoutes creates the GET and PUT /admin/wordfilter/:locale routes, which return and replace
+// the dictionary of the locale. Changes are not persisted and are lost on restart.
+func WordFilterRoutes(f *wordfilter.Filter, a auth.Authenticator) []patronhttp.Route {
+	get := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		return sync.NewResponse(dictionary{Words: f.Words(req.Fields["locale"])}), nil
+	}
+
+	put := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		var body dictionary
+		if err := req.Decode(&body); err != nil {
+			return nil, apierror.Validation("body is not valid")
+		}
+		locale := req.Fields["locale"]
+		f.Set(locale, body.Words)
+		log.Infof("word filter dictionary of locale %s replaced with %d words", locale, len(body.Words))
+		return sync.NewResponse(dictionary{Words: f.Words(locale)}), nil
+	}
+
+	return []patronhttp.Route{
+		patronhttp.NewAuthGetRoute(wordFilterPath, get, true, a),
+		patronhttp.NewAuthPutRoute(wordFilterPath, put, true, a),
+	}
+}
@@ -0,0 +1,240 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	// DefaultTable is the ClickHouse table of the events.
+	DefaultTable = "analytics_events"
+
+	defaultAttempts = 3
+	defaultBackoff  = time.Second
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// clickHouseRow is an event as a row of the events table:
+//
+//	CREATE TABLE analytics_events (
+//	  type LowCardinality(String), article String, depth UInt8, session String, client_id String,
+//	  occurred_at DateTime64(3), received_at DateTime64(3)
+//	) ENGINE = MergeTree PARTITION BY toYYYYMM(occurred_at) ORDER BY (article, occurred_at)
+type clickHouseRow struct {
+	Type       string `json:"type"`
+	Article    string `json:"article"`
+	Depth      int    `json:"depth"`
+	Session    string `json:"session"`
+	ClientID   string `json:"client_id"`
+	OccurredAt string `json:"occurred_at"`
+	ReceivedAt string `json:"received_at"`
+}
+
+// ClickHouseOptionFunc defines an option func for the ClickHouse sink.
+type ClickHouseOptionFunc func(*ClickHouseSink) error
+
+// Table option for setting the table the events are inserted into, optionally qualified by the database.
+func Table(name string) ClickHouseOptionFunc {
+	return func(s *ClickHouseSink) error {
+		if !tableName.MatchString(name) {
+			return errors.Errorf("table name %q is not valid", name)
+		}
+		s.table = name
+		return nil
+	}
+}
+
+// Credentials option for setting the user and password of ClickHouse.
+func Credentials(user, password string) ClickHouseOptionFunc {
+	return func(s *ClickHouseSink) error {
+		if user == "" {
+			return errors.New("user is required")
+		}
+		s.user = user
+		s.password = password
+		return nil
+	}
+}
+
+// InsertRetries option for setting the attempts of an insert and the backoff between them, which doubles after every attempt.
+func InsertRetries(attempts int, backoff time.Duration) ClickHouseOptionFunc {
+	return func(s *ClickHouseSink) error {
+		if attempts <= 0 || backoff <= 0 {
+			return errors.New("attempts and backoff must be positive")
+		}
+		s.attempts = attempts
+		s.backoff = backoff
+		return nil
+	}
+}
+
+// ClickHouseSink inserts the events into ClickHouse with its HTTP interface, one insert per batch,
+// so it should be used with an Ingester batching the events.
+type ClickHouseSink struct {
+	endpoint string
+	table    string
+	user     string
+	password string
+	attempts int
+	backoff  time.Duration
+	cl       *http.Client
+}
+
+// NewClickHouseSink creates a new sink for the ClickHouse HTTP interface, e.g. http://clickhouse:8123.
+func NewClickHouseSink(endpoint string, oo ...ClickHouseOptionFunc) (*ClickHouseSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Errorf("clickhouse endpoint %q is not valid", endpoint)
+	}
+	s := ClickHouseSink{
+		endpoint: endpoint,
+		table:    DefaultTable,
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		cl:       &http.Client{Timeout: writeTimeout},
+	}
+	for _, o := range oo {
+		err := o(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// NewClickHouseSinkFromEnv creates a sink from REALWORLD_CLICKHOUSE_URL, with the table of REALWORLD_CLICKHOUSE_TABLE
+// and the credentials of REALWORLD_CLICKHOUSE_USER and REALWORLD_CLICKHOUSE_PASSWORD.
+// It returns false if REALWORLD_CLICKHOUSE_URL is not set, in which case analytics are disabled.
+func NewClickHouseSinkFromEnv() (*ClickHouseSink, bool, error) {
+	endpoint, ok := os.LookupEnv("REALWORLD_CLICKHOUSE_URL")
+	if !ok {
+		return nil, false, nil
+	}
+	var oo []ClickHouseOptionFunc
+	if t, ok := os.LookupEnv("REALWORLD_CLICKHOUSE_TABLE"); ok {
+		oo = append(oo, Table(t))
+	}
+	if u, ok := os.LookupEnv("REALWORLD_CLICKHOUSE_USER"); ok {
+		oo = append(oo, Credentials(u, os.Getenv("REALWORLD_CLICKHOUSE_PASSWORD")))
+	}
+	s, err := NewClickHouseSink(endpoint, oo...)
+	if err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+// Write inserts the events, retrying failed inserts with backoff. ClickHouse deduplicates
+// retried inserts of the same block into replicated tables, so a retry after a timeout
+// does not duplicate the events.
+func (s *ClickHouseSink) Write(ctx context.Context, ee []Event) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range ee {
+		err := enc.Encode(clickHouseRow{
+			Type:       e.Type,
+			Article:    e.Article,
+			Depth:      e.Depth,
+			Session:    e.Session,
+			ClientID:   e.ClientID,
+			OccurredAt: e.OccurredAt.UTC().Format("2006-01-02 15:04:05.000"),
+			ReceivedAt: e.ReceivedAt.UTC().Format("2006-01-02 15:04:05.000"),
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to encode event")
+		}
+	}
+
+	backoff := s.backoff
+	var err error
+	for i := 0; i < s.attempts; i++ {
+		if i > 0 {
+			log.Warnf("retrying insert of %d events into clickhouse in %s: %v", len(ee), backoff, err)
+			select {
+			case <-ctx.Done():
+				return errors.Wrap(err, "failed to insert events")
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		err = s.insert(ctx, body.Bytes())
+		if err == nil {
+			return nil
+		}
+	}
+	return errors.Wrapf(err, "failed to insert events after %d attempts", s.attempts)
+}
+
+// Check returns the version of ClickHouse, so that the sink can be registered as a status checker.
+func (s *ClickHouseSink) Check(ctx context.Context) (string, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("query", "SELECT version()")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	if s.user != "" {
+		req.Header.Set("X-ClickHouse-User", s.user)
+		req.Header.Set("X-ClickHouse-Key", s.password)
+	}
+	rsp, err := s.cl.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+	b, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 512))
+	if rsp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("clickhouse responded with %d: %s", rsp.StatusCode, bytes.TrimSpace(b))
+	}
+	return string(bytes.TrimSpace(b)), nil
+}
+
+func (s *ClickHouseSink) insert(ctx context.Context, body []byte) error {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("query", "INSERT INTO "+s.table+" FORMAT JSONEachRow")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if s.user != "" {
+		req.Header.Set("X-ClickHouse-User", s.user)
+		req.Header.Set("X-ClickHouse-Key", s.password)
+	}
+	rsp, err := s.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 512))
+		return errors.Errorf("clickhouse responded with %d: %s", rsp.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(ioutil.Discard, rsp.Body)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package analytics ingests the analytics events of the clients, e.g. article views and scroll depth,
+// and writes them to a sink in batches in the background.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgegg/go-patron-realworld-example-app/internal/slug"
+)
+
+const (
+	// ArticleViewed is the type of the event of an article being opened.
+	ArticleViewed = "article_viewed"
+	// ScrollDepth is the type of the event of an article being scrolled, with the depth in percent.
+	ScrollDepth = "scroll_depth"
+
+	// MaxBatch is the maximum number of events of a request.
+	MaxBatch = 100
+	// maxClockSkew is how far in the future the time of an event may be.
+	maxClockSkew = 5 * time.Minute
+	// maxAge is how old the time of an event may be.
+	maxAge = 24 * time.Hour
+)
+
+// Event is an analytics event of a client.
+type Event struct {
+	Type       string    `json:"type"`
+	Article    string    `json:"article"`
+	Depth      int       `json:"depth,omitempty"`
+	Session    string    `json:"session,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// ClientID and ReceivedAt are set by the server.
+	ClientID   string    `json:"-"`
+	ReceivedAt time.Time `json:"-"`
+}
+
+// Validate checks the event, returning a message describing the problem.
+func (e Event) Validate(now time.Time) string {
+	switch e.Type {
+	case ArticleViewed:
+	case ScrollDepth:
+		if e.Depth < 0 || e.Depth > 100 {
+			return "depth must be between 0 and 100"
+		}
+	default:
+		return fmt.Sprintf("unknown event type %q", e.Type)
+	}
+	if err := slug.Validate(e.Article); err != nil {
+		return "article is not a valid slug"
+	}
+	if len(e.Session) > 64 {
+		return "session is too long"
+	}
+	if e.OccurredAt.IsZero() {
+		return "occurredAt is required"
+	}
+	if e.OccurredAt.After(now.Add(maxClockSkew)) || e.OccurredAt.Before(now.Add(-maxAge)) {
+		return "occurredAt is out of range"
+	}
+	return ""
+}
+
+// Sink interface for writing events, e.g. to Kafka or ClickHouse.
+type Sink interface {
+	Write(ctx context.Context, ee []Event) error
+}
@@ -0,0 +1,165 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultQueueSize     = 10000
+	defaultBatchSize     = 500
+	defaultFlushInterval = 5 * time.Second
+	writeTimeout         = 30 * time.Second
+)
+
+var eventCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "analytics",
+	Name:      "events_total",
+	Help:      "Number of analytics events, by result.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(eventCounter)
+}
+
+// ErrQueueFull is returned when the events can't be queued, since the sink does not keep up.
+var ErrQueueFull = errors.New("analytics queue is full")
+
+// OptionFunc defines an option func for the ingester.
+type OptionFunc func(*Ingester) error
+
+// QueueSize option for setting the maximum number of queued events.
+func QueueSize(n int) OptionFunc {
+	return func(in *Ingester) error {
+		if n <= 0 {
+			return errors.New("queue size must be positive")
+		}
+		in.queue = make(chan Event, n)
+		return nil
+	}
+}
+
+// Batch option for setting the maximum size of a batch written to the sink, and the interval after
+// which a smaller batch is written.
+func Batch(size int, interval time.Duration) OptionFunc {
+	return func(in *Ingester) error {
+		if size <= 0 || interval <= 0 {
+			return errors.New("batch size and interval must be positive")
+		}
+		in.batchSize = size
+		in.flushInterval = interval
+		return nil
+	}
+}
+
+// Ingester queues the events and writes them to the sink in batches.
+// It implements the patron Component interface.
+type Ingester struct {
+	sink          Sink
+	queue         chan Event
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// NewIngester creates a new ingester writing to the sink.
+func NewIngester(s Sink, oo ...OptionFunc) (*Ingester, error) {
+	if s == nil {
+		return nil, errors.New("sink is nil")
+	}
+	in := Ingester{
+		sink:          s,
+		queue:         make(chan Event, defaultQueueSize),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+	}
+	for _, o := range oo {
+		err := o(&in)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &in, nil
+}
+
+// Enqueue queues the events without blocking. Either all events are queued or, if there is
+// not enough room, none and ErrQueueFull is returned.
+func (in *Ingester) Enqueue(ee []Event) error {
+	if cap(in.queue)-len(in.queue) < len(ee) {
+		eventCounter.WithLabelValues("dropped").Add(float64(len(ee)))
+		return ErrQueueFull
+	}
+	for _, e := range ee {
+		select {
+		case in.queue <- e:
+		default:
+			// another request filled the queue in the meantime.
+			eventCounter.WithLabelValues("dropped").Inc()
+		}
+	}
+	return nil
+}
+
+// Run writes the queued events to the sink until the context is cancelled,
+// and then writes the events still queued before returning.
+func (in *Ingester) Run(ctx context.Context) error {
+	tc := time.NewTicker(in.flushInterval)
+	defer tc.Stop()
+
+	batch := make([]Event, 0, in.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-in.queue:
+					batch = append(batch, e)
+					if len(batch) == in.batchSize {
+						batch = in.flush(batch)
+					}
+				default:
+					in.flush(batch)
+					return nil
+				}
+			}
+		case e := <-in.queue:
+			batch = append(batch, e)
+			if len(batch) == in.batchSize {
+				batch = in.flush(batch)
+			}
+		case <-tc.C:
+			batch = in.flush(batch)
+		}
+	}
+}
+
+// Info returns information of the component.
+func (in *Ingester) Info() map[string]interface{} {
+	return map[string]interface{}{
+		"type":           "analytics-ingester",
+		"queue-size":     cap(in.queue),
+		"batch-size":     in.batchSize,
+		"flush-interval": in.flushInterval.String(),
+	}
+}
+
+// flush writes the batch to the sink, returning the emptied batch. The sink has its own context,
+// so that the last batches are written on shutdown.
+func (in *Ingester) flush(batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := in.sink.Write(ctx, batch); err != nil {
+		log.Errorf("failed to write %d analytics events: %v", len(batch), err)
+		eventCounter.WithLabelValues("failed").Add(float64(len(batch)))
+	} else {
+		eventCounter.WithLabelValues("written").Add(float64(len(batch)))
+	}
+	return batch[:0]
+}
@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/payload"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/ratelimit"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+// Path is the path of the events route, relative to the API group.
+const Path = "/events"
+
+// ClientHeader is the header identifying the client sending the events. Clients without it are identified by IP.
+// It is recorded on the events only, since the client controls it.
+const ClientHeader = "X-Client-ID"
+
+type eventsRequest struct {
+	Events []Event `json:"events"`
+}
+
+type eventsResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// ClientKey returns the client of the request, recorded on its events.
+func ClientKey(r *http.Request) string {
+	if id := r.Header.Get(ClientHeader); id != "" && len(id) <= 64 {
+		return "client:" + id
+	}
+	return "ip:" + ratelimit.ClientIP(r)
+}
+
+// Spec returns the spec of the POST /events route of the API, queuing a batch of events for the ingester.
+// The requests are limited by the rate limit class.
+func Spec(in *Ingester, rateClass string) route.Spec {
+	return route.Spec{
+		Method:      http.MethodPost,
+		Path:        Path,
+		Processor:   processor(in),
+		RateClass:   rateClass,
+		Middlewares: []patronhttp.MiddlewareFunc{clientMiddleware},
+	}
+}
+
+type clientKey struct{}
+
+// clientMiddleware passes the client of the request to the processor, which has no access to the remote address.
+func clientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, ClientKey(r))))
+	})
+}
+
+func processor(in *Ingester) sync.ProcessorFunc {
+	return func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		var body eventsRequest
+		if err := payload.Decode(req.Raw, &body); err != nil {
+			return nil, err
+		}
+		if len(body.Events) == 0 {
+			return nil, apierror.Validation("events can't be empty")
+		}
+		if len(body.Events) > MaxBatch {
+			return nil, apierror.Validation(fmt.Sprintf("at most %d events can be sent at once", MaxBatch))
+		}
+
+		now := time.Now().UTC()
+		client, _ := ctx.Value(clientKey{}).(string)
+		var msgs []string
+		for i := range body.Events {
+			if msg := body.Events[i].Validate(now); msg != "" {
+				msgs = append(msgs, fmt.Sprintf("events[%d]: %s", i, msg))
+			}
+			body.Events[i].ClientID = client
+			body.Events[i].ReceivedAt = now
+		}
+		if len(msgs) > 0 {
+			return nil, apierror.Validation(msgs...)
+		}
+
+		if err := in.Enqueue(body.Events); err != nil {
+			log.FromContext(ctx).Warnf("rejected %d analytics events: %v", len(body.Events), err)
+			return nil, apierror.New(http.StatusServiceUnavailable, "events can't be accepted right now")
+		}
+		return sync.NewResponse(eventsResponse{Accepted: len(body.Events)}), nil
+	}
+}
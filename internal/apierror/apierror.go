@@ -0,0 +1,37 @@
+// Package apierror creates patron HTTP errors carrying the RealWorld error envelope.
+package apierror
+
+import (
+	"net/http"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+// Body definition of the RealWorld error envelope.
+type Body struct {
+	Errors struct {
+		Body []string `json:"body"`
+	} `json:"errors"`
+}
+
+// NewBody creates a new error envelope with the messages.
+func NewBody(msgs ...string) Body {
+	var b Body
+	b.Errors.Body = msgs
+	return b
+}
+
+// New creates a new patron HTTP error with the status code and a RealWorld error body.
+func New(code int, msgs ...string) *patronhttp.Error {
+	return patronhttp.NewErrorWithCodeAndPayload(code, NewBody(msgs...))
+}
+
+// Validation creates a new 422 error, the status the RealWorld spec uses for validation errors.
+func Validation(msgs ...string) *patronhttp.Error {
+	return New(http.StatusUnprocessableEntity, msgs...)
+}
+
+// Internal creates a new 500 error.
+func Internal(msgs ...string) *patronhttp.Error {
+	return New(http.StatusInternalServerError, msgs...)
+}
@@ -0,0 +1,25 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/beatlabs/patron/encoding"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+)
+
+// Write writes a RealWorld error envelope with the status code, for middlewares and raw handlers
+// which can't return a patron error.
+func Write(w http.ResponseWriter, code int, msg string) {
+	p, err := json.Marshal(NewBody(msg))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(encoding.ContentTypeHeader, patronjson.TypeCharset)
+	w.WriteHeader(code)
+	if _, err := w.Write(p); err != nil {
+		log.Errorf("failed to write error response: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package apikey implements API keys for read-only machine clients.
+// Keys are only shown once on creation; the store keeps a SHA-256 hash of them.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const (
+	keyPrefix = "rw_"
+	keyBytes  = 32
+)
+
+var (
+	// ErrNotFound is returned when a key does not exist or is revoked.
+	ErrNotFound = errors.New("api key not found")
+)
+
+// Key definition of a stored API key.
+type Key struct {
+	ID        string
+	UserID    string
+	Name      string
+	Hash      string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Revoked returns true if the key has been revoked.
+func (k Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Store interface for persisting API keys.
+type Store interface {
+	Create(k Key) error
+	List(userID string) ([]Key, error)
+	Revoke(userID, id string) error
+	FindByHash(hash string) (Key, error)
+}
+
+// New generates a new API key for the user. It returns the plain text key,
+// which has to be handed to the client, and the key to be stored.
+func New(userID, name string) (string, Key, error) {
+	if userID == "" {
+		return "", Key{}, errors.New("user id is required")
+	}
+	if name == "" {
+		return "", Key{}, errors.New("name is required")
+	}
+
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", Key{}, errors.Wrap(err, "failed to generate api key")
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", Key{}, errors.Wrap(err, "failed to generate api key id")
+	}
+
+	plain := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return plain, Key{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		Name:      name,
+		Hash:      Hash(plain),
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Hash returns the hash of a plain text key as stored.
+func Hash(plain string) string {
+	h := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(h[:])
+}
+
+// MemoryStore is an in-memory implementation of the Store.
+type MemoryStore struct {
+	sync.RWMutex
+	keys map[string]Key
+	// ids are the IDs of the keys by hash, since every authenticated request looks a key up by its hash.
+	ids map[string]string
+}
+
+// NewMemoryStore creates a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]Key), ids: make(map[string]string)}
+}
+
+// Create stores a key.
+func (s *MemoryStore) Create(k Key) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.keys[k.ID]; ok {
+		return errors.Errorf("api key %s already exists", k.ID)
+	}
+	if _, ok := s.ids[k.Hash]; ok {
+		return errors.New("api key hash already exists")
+	}
+	s.keys[k.ID] = k
+	s.ids[k.Hash] = k.ID
+	return nil
+}
+
+// List returns the keys of a user, including the revoked ones, oldest first.
+func (s *MemoryStore) List(userID string) ([]Key, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var kk []Key
+	for _, k := range s.keys {
+		if k.UserID == userID {
+			kk = append(kk, k)
+		}
+	}
+	sort.Slice(kk, func(i, j int) bool { return kk[i].CreatedAt.Before(kk[j].CreatedAt) })
+	return kk, nil
+}
+
+// Revoke marks a key of a user as revoked.
+func (s *MemoryStore) Revoke(userID, id string) error {
+	s.Lock()
+	defer s.Unlock()
+	k, ok := s.keys[id]
+	if !ok || k.UserID != userID || k.Revoked() {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	k.RevokedAt = &now
+	s.keys[id] = k
+	return nil
+}
+
+// FindByHash returns the active key with the provided hash.
+func (s *MemoryStore) FindByHash(hash string) (Key, error) {
+	s.RLock()
+	defer s.RUnlock()
+	k, ok := s.keys[s.ids[hash]]
+	if !ok || k.Revoked() {
+		return Key{}, ErrNotFound
+	}
+	return k, nil
+}
@@ -0,0 +1,108 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+const scheme = "ApiKey "
+
+type keyCtx struct{}
+
+// FromContext returns the API key that authenticated the request of the context, whose UserID is the
+// user the request is made for.
+func FromContext(ctx context.Context) (Key, bool) {
+	k, ok := ctx.Value(keyCtx{}).(Key)
+	return k, ok
+}
+
+// Middleware creates a MiddlewareFunc adding the active API key of the request to the context.
+// Requests without an active key are served unchanged, so that the authenticator can reject them.
+func Middleware(s Store) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plain, ok := FromRequest(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			k, err := s.FindByHash(Hash(plain))
+			if err != nil {
+				if err != ErrNotFound {
+					log.FromContext(r.Context()).Errorf("failed to find api key: %v", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyCtx{}, k)))
+		})
+	}
+}
+
+// Consumer returns a route.ConsumerFunc identifying the requests with an API key in the context by the ID of
+// the key, whose number is bounded by the keys issued, and the other requests by the next func.
+// The keys are added to the context by the Middleware, which has to run before the routes.
+func Consumer(next route.ConsumerFunc) route.ConsumerFunc {
+	return func(r *http.Request) string {
+		if k, ok := FromContext(r.Context()); ok {
+			return "apikey:" + k.ID
+		}
+		return next(r)
+	}
+}
+
+// Authenticator authenticates read-only requests carrying an `Authorization: ApiKey ...` header.
+// It implements the patron auth.Authenticator interface.
+type Authenticator struct {
+	store Store
+}
+
+// NewAuthenticator creates a new authenticator backed by the store.
+func NewAuthenticator(s Store) (*Authenticator, error) {
+	if s == nil {
+		return nil, errors.New("store is nil")
+	}
+	return &Authenticator{store: s}, nil
+}
+
+// Authenticate returns true if the request carries an active API key and is read-only.
+// Keys already found by the middleware are not looked up again.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false, nil
+	}
+	if _, ok := FromContext(req.Context()); ok {
+		return true, nil
+	}
+	plain, ok := FromRequest(req)
+	if !ok {
+		return false, nil
+	}
+	_, err := a.store.FindByHash(Hash(plain))
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to find api key")
+	}
+	return true, nil
+}
+
+// FromRequest extracts the plain text API key from the Authorization header.
+func FromRequest(req *http.Request) (string, bool) {
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, scheme) {
+		return "", false
+	}
+	plain := strings.TrimSpace(strings.TrimPrefix(h, scheme))
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return "", false
+	}
+	return plain, true
+}
@@ -0,0 +1,93 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func deprecatedRequests(t *testing.T, pattern, consumer string) float64 {
+	t.Helper()
+	mff, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, mf := range mff {
+		if mf.GetName() != "realworld_http_deprecated_requests_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["route"] == pattern && labels["consumer"] == consumer {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestConsumerOfDeprecatedRoute(t *testing.T) {
+	store := NewMemoryStore()
+	plain, k, err := New("user-1", "site generator")
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := store.Create(k); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	reg, err := route.NewRegistry(route.Consumer(Consumer(route.DefaultConsumer)))
+	if err != nil {
+		t.Fatalf("NewRegistry() error: %v", err)
+	}
+	err = reg.Add(route.Spec{
+		Method: http.MethodGet,
+		Path:   "/articles/feed",
+		Processor: func(context.Context, *sync.Request) (*sync.Response, error) {
+			return sync.NewResponse("ok"), nil
+		},
+		Deprecation: &route.Deprecation{Since: time.Unix(1700000000, 0)},
+	})
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	r := reg.Apply(route.NewGroup(route.APIPrefix)).Routes()[0]
+	// the key middleware runs globally, ahead of the router.
+	h := Middleware(store)(patronhttp.MiddlewareChain(r.Handler, r.Middlewares...))
+
+	tests := map[string]struct {
+		auth     string
+		consumer string
+	}{
+		"api key":       {auth: "ApiKey " + plain, consumer: "apikey:" + k.ID},
+		"unknown key":   {auth: "ApiKey rw_unknown", consumer: "anonymous"},
+		"without a key": {consumer: "anonymous"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			before := deprecatedRequests(t, "GET /api/articles/feed", tt.consumer)
+			req := httptest.NewRequest(http.MethodGet, "/api/articles/feed", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Header().Get("Deprecation") == "" {
+				t.Error("response has no Deprecation header")
+			}
+			if got := deprecatedRequests(t, "GET /api/articles/feed", tt.consumer) - before; got != 1 {
+				t.Errorf("deprecated requests of consumer %s increased by %v, want 1", tt.consumer, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/sync"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+const (
+	// KeysPath is the path of the API keys of the current user, relative to the API group.
+	KeysPath = "/user/api-keys"
+	keyPath  = KeysPath + "/:id"
+
+	maxNameLength = 64
+)
+
+// UserFunc returns the ID of the user authenticated by the request of the context.
+type UserFunc func(ctx context.Context) (string, bool)
+
+type keyView struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Key is the plain text key, returned only on creation.
+	Key string `json:"key,omitempty"`
+}
+
+func view(k Key) keyView {
+	return keyView{ID: k.ID, Name: k.Name, CreatedAt: k.CreatedAt, RevokedAt: k.RevokedAt}
+}
+
+type createRequest struct {
+	APIKey struct {
+		Name string `json:"name"`
+	} `json:"apiKey"`
+}
+
+// Specs returns the specs of the POST and GET /user/api-keys and DELETE /user/api-keys/:id routes of the API,
+// which create, list and revoke the API keys of the current user. The routes require authentication, so
+// the authenticator of the registry has to authenticate users, and the user func to return the user of the request.
+func Specs(s Store, user UserFunc) []route.Spec {
+	create := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		userID, ok := user(ctx)
+		if !ok {
+			return nil, apierror.New(http.StatusUnauthorized, "user is not authenticated")
+		}
+		var body createRequest
+		if err := req.Decode(&body); err != nil {
+			return nil, apierror.Validation("body is not valid")
+		}
+		name := strings.TrimSpace(body.APIKey.Name)
+		if name == "" {
+			return nil, apierror.Validation("name can't be blank")
+		}
+		if len(name) > maxNameLength {
+			return nil, apierror.Validation("name is too long")
+		}
+		plain, k, err := New(userID, name)
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to create api key: %v", err)
+			return nil, apierror.Internal("failed to create api key")
+		}
+		if err := s.Create(k); err != nil {
+			log.FromContext(ctx).Errorf("failed to store api key: %v", err)
+			return nil, apierror.Internal("failed to create api key")
+		}
+		v := view(k)
+		v.Key = plain
+		return sync.NewResponse(map[string]keyView{"apiKey": v}), nil
+	}
+
+	list := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		userID, ok := user(ctx)
+		if !ok {
+			return nil, apierror.New(http.StatusUnauthorized, "user is not authenticated")
+		}
+		kk, err := s.List(userID)
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to list api keys: %v", err)
+			return nil, apierror.Internal("failed to list api keys")
+		}
+		vv := make([]keyView, 0, len(kk))
+		for _, k := range kk {
+			vv = append(vv, view(k))
+		}
+		return sync.NewResponse(map[string][]keyView{"apiKeys": vv}), nil
+	}
+
+	revoke := func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		userID, ok := user(ctx)
+		if !ok {
+			return nil, apierror.New(http.StatusUnauthorized, "user is not authenticated")
+		}
+		err := s.Revoke(userID, req.Fields["id"])
+		if err == ErrNotFound {
+			return nil, apierror.New(http.StatusNotFound, "api key not found")
+		}
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to revoke api key: %v", err)
+			return nil, apierror.Internal("failed to revoke api key")
+		}
+		return nil, nil
+	}
+
+	return []route.Spec{
+		{Method: http.MethodPost, Path: KeysPath, Processor: create, Auth: true},
+		{Method: http.MethodGet, Path: KeysPath, Processor: list, Auth: true},
+		{Method: http.MethodDelete, Path: keyPath, Processor: revoke, Auth: true},
+	}
+}
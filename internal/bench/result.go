@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Buckets are the upper bounds of the latency histogram.
+var Buckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// Result of a scenario run.
+type Result struct {
+	sync.Mutex
+	Scenario  string
+	Elapsed   time.Duration
+	Latencies []time.Duration
+	Codes     map[int]int
+	Errors    int
+	Dropped   int
+}
+
+func newResult(scenario string) *Result {
+	return &Result{Scenario: scenario, Codes: make(map[int]int)}
+}
+
+func (r *Result) record(d time.Duration, code int, err error) {
+	r.Lock()
+	defer r.Unlock()
+	if err != nil {
+		r.Errors++
+		return
+	}
+	r.Latencies = append(r.Latencies, d)
+	r.Codes[code]++
+}
+
+func (r *Result) drop() {
+	r.Lock()
+	r.Dropped++
+	r.Unlock()
+}
+
+// Percentile returns the latency at the percentile p in [0, 100].
+func (r *Result) Percentile(p float64) time.Duration {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	ll := make([]time.Duration, len(r.Latencies))
+	copy(ll, r.Latencies)
+	sort.Slice(ll, func(i, j int) bool { return ll[i] < ll[j] })
+	idx := int(p / 100 * float64(len(ll)-1))
+	return ll[idx]
+}
+
+// Histogram returns the number of requests per latency bucket.
+// The last element counts the requests above the largest bucket.
+func (r *Result) Histogram() []int {
+	r.Lock()
+	defer r.Unlock()
+	hh := make([]int, len(Buckets)+1)
+	for _, l := range r.Latencies {
+		i := sort.Search(len(Buckets), func(i int) bool { return l <= Buckets[i] })
+		hh[i]++
+	}
+	return hh
+}
+
+// Report writes a human readable report of the result.
+func (r *Result) Report(w io.Writer) error {
+	p50, p90, p99 := r.Percentile(50), r.Percentile(90), r.Percentile(99)
+	hh := r.Histogram()
+
+	r.Lock()
+	defer r.Unlock()
+
+	_, err := fmt.Fprintf(w, "scenario %s: %d requests in %s, %d errors, %d dropped\n",
+		r.Scenario, len(r.Latencies), r.Elapsed.Round(time.Millisecond), r.Errors, r.Dropped)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "  latency p50=%s p90=%s p99=%s\n", p50, p90, p99)
+	if err != nil {
+		return err
+	}
+
+	codes := make([]int, 0, len(r.Codes))
+	for c := range r.Codes {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		_, err = fmt.Fprintf(w, "  status %d: %d\n", c, r.Codes[c])
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, c := range hh {
+		bound := "+Inf"
+		if i < len(Buckets) {
+			bound = Buckets[i].String()
+		}
+		_, err = fmt.Fprintf(w, "  le %8s: %d\n", bound, c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
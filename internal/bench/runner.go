@@ -0,0 +1,118 @@
+package bench
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// Runner executes scenarios against a running instance of the API.
+type Runner struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	workers int
+}
+
+// NewRunner creates a new runner for the base URL. The token is sent with scenarios requiring authentication.
+func NewRunner(baseURL, token string, workers int) (*Runner, error) {
+	if baseURL == "" {
+		return nil, errors.New("base url is required")
+	}
+	if workers <= 0 {
+		return nil, errors.New("workers must be positive")
+	}
+	return &Runner{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		workers: workers,
+	}, nil
+}
+
+// Run executes the scenario and returns the result.
+// Requests are paced at the scenario rate; if all workers are busy the request is counted as dropped,
+// so that a slow server does not reduce the offered load (coordinated omission).
+func (r *Runner) Run(ctx context.Context, s Scenario) (*Result, error) {
+	if s.Rate <= 0 || s.Duration <= 0 {
+		return nil, errors.Errorf("scenario %s: rate and duration must be positive", s.Name)
+	}
+	if s.Rate > MaxRate {
+		return nil, errors.Errorf("scenario %s: rate must be at most %d", s.Name, MaxRate)
+	}
+	if s.Auth && r.token == "" {
+		return nil, errors.Errorf("scenario %s requires a token", s.Name)
+	}
+
+	// in flight requests use the parent context so that they are not cancelled when the scenario ends.
+	reqCtx := ctx
+	ctx, cnl := context.WithTimeout(ctx, s.Duration)
+	defer cnl()
+
+	res := newResult(s.Name)
+	chReq := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(r.workers)
+	for i := 0; i < r.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for range chReq {
+				start := time.Now()
+				code, err := r.do(reqCtx, s)
+				res.record(time.Since(start), code, err)
+			}
+		}()
+	}
+
+	tc := time.NewTicker(time.Second / time.Duration(s.Rate))
+	defer tc.Stop()
+
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-tc.C:
+			select {
+			case chReq <- struct{}{}:
+			default:
+				res.drop()
+			}
+		}
+	}
+	close(chReq)
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+	return res, nil
+}
+
+func (r *Runner) do(ctx context.Context, s Scenario) (int, error) {
+	var body io.Reader
+	if s.Body != "" {
+		body = strings.NewReader(s.Body)
+	}
+	req, err := http.NewRequest(s.Method, r.baseURL+s.Path, body)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if s.Auth {
+		req.Header.Set("Authorization", "Token "+r.token)
+	}
+
+	rsp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	_, err = io.Copy(ioutil.Discard, rsp.Body)
+	return rsp.StatusCode, err
+}
@@ -0,0 +1,44 @@
+// Package bench contains reproducible load scenarios for the hot endpoints of the API
+// and a runner that reports their latency distribution.
+package bench
+
+import (
+	"net/http"
+	"time"
+)
+
+// MaxRate is the maximum rate of a scenario in requests per second, beyond which the requests can't be paced.
+const MaxRate = 100000
+
+// Scenario definition of a load scenario. Requests are issued at a constant rate
+// for the duration of the scenario, which makes runs comparable between releases.
+type Scenario struct {
+	Name     string
+	Method   string
+	Path     string
+	Body     string
+	Auth     bool
+	Rate     int
+	Duration time.Duration
+}
+
+// DefaultScenarios returns the scenarios for the endpoints served by the API. Other endpoints,
+// e.g. the article lists once they are served, can be run as custom scenarios.
+func DefaultScenarios() []Scenario {
+	return []Scenario{
+		{
+			Name:     "version",
+			Method:   http.MethodGet,
+			Path:     "/api/version",
+			Rate:     200,
+			Duration: 30 * time.Second,
+		},
+		{
+			Name:     "status",
+			Method:   http.MethodGet,
+			Path:     "/status",
+			Rate:     50,
+			Duration: 30 * time.Second,
+		},
+	}
+}
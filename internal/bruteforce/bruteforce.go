@@ -0,0 +1,295 @@
+// Package bruteforce protects the login from brute-force attacks by delaying and locking out
+// accounts and IPs with repeated failed attempts.
+package bruteforce
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+const (
+	// EventFailure is emitted on every failed login attempt.
+	EventFailure = "login.failure"
+	// EventLockout is emitted when an account or IP gets locked out.
+	EventLockout = "login.lockout"
+	// EventBlocked is emitted when an attempt is rejected because of a delay or lockout.
+	EventBlocked = "login.blocked"
+)
+
+// Policy definition of the failures tolerated before attempts are delayed and locked out.
+// After each failure the next attempt is delayed by BaseDelay, doubling up to MaxDelay.
+// After MaxFailures failures attempts are rejected for Lockout.
+// Failures are forgotten after Window without any failure.
+type Policy struct {
+	MaxFailures int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Lockout     time.Duration
+	Window      time.Duration
+}
+
+// DefaultPolicy returns the default policy of accounts.
+func DefaultPolicy() Policy {
+	return Policy{MaxFailures: 5, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Lockout: 15 * time.Minute, Window: time.Hour}
+}
+
+// DefaultIPPolicy returns the default policy of IPs, which tolerates more failures
+// since an IP can be shared by many users.
+func DefaultIPPolicy() Policy {
+	return Policy{MaxFailures: 50, BaseDelay: 0, MaxDelay: 0, Lockout: 15 * time.Minute, Window: time.Hour}
+}
+
+func (p Policy) validate() error {
+	if p.MaxFailures <= 0 {
+		return errors.New("max failures must be positive")
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < p.BaseDelay {
+		return errors.New("delays must not be negative and max delay must not be less than base delay")
+	}
+	if p.Lockout <= 0 || p.Window <= 0 {
+		return errors.New("lockout and window must be positive")
+	}
+	return nil
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	if failures <= 0 || p.BaseDelay == 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// Record of the failed attempts of an account or IP.
+type Record struct {
+	Failures    int
+	Last        time.Time
+	LockedUntil time.Time
+}
+
+// Limits of a failure, computed from the policy of the key.
+// Failures are forgotten after Window without any failure, and the key is locked out for Lockout
+// once it reaches MaxFailures. The record has to be kept for TTL.
+type Limits struct {
+	MaxFailures int
+	Window      time.Duration
+	Lockout     time.Duration
+	TTL         time.Duration
+}
+
+// Next returns the record after a failure at the time, and true if the failure locked it out.
+// Stores can apply it to the current record inside their transaction or script.
+func (l Limits) Next(r Record, now time.Time) (Record, bool) {
+	if now.Sub(r.Last) > l.Window {
+		r = Record{}
+	}
+	r.Failures++
+	r.Last = now
+	if r.Failures >= l.MaxFailures && !now.Before(r.LockedUntil) {
+		r.LockedUntil = now.Add(l.Lockout)
+		return r, true
+	}
+	return r, false
+}
+
+// Store interface for persisting the records, e.g. in Redis or the database, so that they are shared by all instances.
+type Store interface {
+	Get(key string) (Record, bool, error)
+	// Incr atomically records a failure of the key at the time within the limits, returning the
+	// updated record and true if the failure locked the key out.
+	Incr(key string, now time.Time, l Limits) (Record, bool, error)
+	Delete(key string) error
+}
+
+// Event is a security event to be written to the audit log.
+type Event struct {
+	Type     string
+	Account  string
+	IP       string
+	Failures int
+	Time     time.Time
+}
+
+// EventFunc handles a security event.
+type EventFunc func(Event)
+
+// OptionFunc defines an option func for the guard.
+type OptionFunc func(*Guard) error
+
+// Events option for setting the handler of the security events. By default they are logged.
+func Events(f EventFunc) OptionFunc {
+	return func(g *Guard) error {
+		if f == nil {
+			return errors.New("event func is nil")
+		}
+		g.events = f
+		return nil
+	}
+}
+
+// BlockedError is returned when a login attempt is delayed or locked out.
+type BlockedError struct {
+	RetryAfter time.Duration
+}
+
+// Error returns the message of the error.
+func (e *BlockedError) Error() string {
+	return "too many failed login attempts, retry after " + e.RetryAfter.Round(time.Second).String()
+}
+
+// HTTPError converts the error to a 429 patron HTTP error with a RealWorld error body.
+func (e *BlockedError) HTTPError() *patronhttp.Error {
+	return apierror.New(http.StatusTooManyRequests, e.Error())
+}
+
+// Guard tracks the failed login attempts per account and IP.
+type Guard struct {
+	account Policy
+	ip      Policy
+	store   Store
+	events  EventFunc
+	now     func() time.Time
+}
+
+// NewGuard creates a new guard with the policies of accounts and IPs.
+func NewGuard(account, ip Policy, s Store, oo ...OptionFunc) (*Guard, error) {
+	if err := account.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid account policy")
+	}
+	if err := ip.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid ip policy")
+	}
+	if s == nil {
+		return nil, errors.New("store is nil")
+	}
+	g := Guard{account: account, ip: ip, store: s, events: logEvent, now: time.Now}
+	for _, o := range oo {
+		err := o(&g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &g, nil
+}
+
+// Check has to be called before verifying the credentials. It returns a BlockedError
+// if the account or the IP is delayed or locked out.
+func (g *Guard) Check(account, ip string) error {
+	var retry time.Duration
+	for _, k := range g.keys(account, ip) {
+		r, ok, err := g.store.Get(k.key)
+		if err != nil {
+			return errors.Wrap(err, "failed to get login attempts")
+		}
+		if !ok {
+			continue
+		}
+		if d := g.retryAfter(k.policy, r); d > retry {
+			retry = d
+		}
+	}
+	if retry > 0 {
+		g.emit(EventBlocked, account, ip, 0)
+		return &BlockedError{RetryAfter: retry}
+	}
+	return nil
+}
+
+// Failure records a failed login attempt of the account from the IP.
+func (g *Guard) Failure(account, ip string) error {
+	now := g.now()
+	failures := 0
+	for i, k := range g.keys(account, ip) {
+		r, locked, err := g.store.Incr(k.key, now, k.policy.limits())
+		if err != nil {
+			return errors.Wrap(err, "failed to store login attempts")
+		}
+		if locked {
+			g.emit(EventLockout, account, ip, r.Failures)
+		}
+		if i == 0 {
+			failures = r.Failures
+		}
+	}
+	g.emit(EventFailure, account, ip, failures)
+	return nil
+}
+
+// Success resets the failed attempts of the account. The failures of the IP are kept,
+// so that an attacker cannot reset them by logging in to an own account.
+func (g *Guard) Success(account string) error {
+	return g.store.Delete(accountKey(account))
+}
+
+// policyKey is a store key with its policy. The account key comes first.
+type policyKey struct {
+	key    string
+	policy Policy
+}
+
+func (g *Guard) keys(account, ip string) []policyKey {
+	return []policyKey{
+		{key: accountKey(account), policy: g.account},
+		{key: "ip:" + strings.TrimSpace(ip), policy: g.ip},
+	}
+}
+
+// accountKey returns the key of the account, which is normalized so that the failures can't be
+// spread over variants of the same email.
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+// limits returns the limits of a failure, keeping the record for the longer of the window and the lockout.
+func (p Policy) limits() Limits {
+	l := Limits{MaxFailures: p.MaxFailures, Window: p.Window, Lockout: p.Lockout, TTL: p.Window}
+	if p.Lockout > p.Window {
+		l.TTL = p.Lockout
+	}
+	return l
+}
+
+func (g *Guard) retryAfter(p Policy, r Record) time.Duration {
+	now := g.now()
+	if now.Before(r.LockedUntil) {
+		return r.LockedUntil.Sub(now)
+	}
+	if now.Sub(r.Last) > p.Window {
+		return 0
+	}
+	next := r.Last.Add(p.delay(r.Failures))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+func (g *Guard) emit(tp, account, ip string, failures int) {
+	g.events(Event{Type: tp, Account: account, IP: ip, Failures: failures, Time: g.now().UTC()})
+}
+
+// logEvent logs the event with a hash of the account instead of the email, which is personal data.
+func logEvent(e Event) {
+	log.Warnf("security event %s for account %s from %s with %d failures", e.Type, accountHash(e.Account), e.IP, e.Failures)
+}
+
+// accountHash returns a short hash of the normalized account, which correlates the events of an
+// account in the logs without revealing it.
+func accountHash(account string) string {
+	sum := sha256.Sum256([]byte(accountKey(account)))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,71 @@
+package bruteforce
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryRecord struct {
+	record  Record
+	expires time.Time
+}
+
+// MemoryStore is an in-memory implementation of the Store, for single instance deployments.
+type MemoryStore struct {
+	sync.Mutex
+	records map[string]memoryRecord
+	now     func() time.Time
+}
+
+// NewMemoryStore creates a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]memoryRecord), now: time.Now}
+}
+
+// Get returns the record of the key, if it exists and has not expired.
+func (s *MemoryStore) Get(key string) (Record, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.records[key]
+	if !ok {
+		return Record{}, false, nil
+	}
+	if !s.now().Before(r.expires) {
+		delete(s.records, key)
+		return Record{}, false, nil
+	}
+	return r.record, true, nil
+}
+
+// Incr records a failure of the key, expiring after the TTL of the limits.
+func (s *MemoryStore) Incr(key string, now time.Time, l Limits) (Record, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.records[key]
+	if !ok || !s.now().Before(r.expires) {
+		r = memoryRecord{}
+	}
+	rec, locked := l.Next(r.record, now)
+	s.records[key] = memoryRecord{record: rec, expires: s.now().Add(l.TTL)}
+	return rec, locked, nil
+}
+
+// Delete removes the record of the key.
+func (s *MemoryStore) Delete(key string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Prune removes the expired records. It is meant to be run periodically.
+func (s *MemoryStore) Prune() {
+	s.Lock()
+	defer s.Unlock()
+	now := s.now()
+	for k, r := range s.records {
+		if !now.Before(r.expires) {
+			delete(s.records, k)
+		}
+	}
+}
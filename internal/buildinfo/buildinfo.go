@@ -0,0 +1,85 @@
+// Package buildinfo describes the build of the binary and exposes it as a route, a metric and in /info.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/beatlabs/patron/info"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const unknown = "unknown"
+
+var buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "realworld",
+	Name:      "build_info",
+	Help:      "Build info of the binary, the value is always 1.",
+}, []string{"version", "revision", "branch", "goversion"})
+
+func init() {
+	prometheus.MustRegister(buildInfo)
+}
+
+// Module is a Go module the binary was built with.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// Info of the build.
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	Branch    string   `json:"branch"`
+	BuildDate string   `json:"buildDate"`
+	GoVersion string   `json:"goVersion"`
+	Module    Module   `json:"module"`
+	Deps      []Module `json:"deps,omitempty"`
+}
+
+// New creates the build info from the values injected with ldflags. Empty values fall back to the
+// VCS info stamped by the go tool, if any (the build date to the commit time), and to "unknown".
+func New(version, commit, branch, date string) Info {
+	i := Info{
+		Version:   version,
+		Commit:    commit,
+		Branch:    branch,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		i.Module = Module{Path: bi.Main.Path, Version: bi.Main.Version}
+		for _, d := range bi.Deps {
+			i.Deps = append(i.Deps, Module{Path: d.Path, Version: d.Version})
+		}
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && i.Commit == "":
+				i.Commit = s.Value
+			case s.Key == "vcs.time" && i.BuildDate == "":
+				i.BuildDate = s.Value
+			}
+		}
+	}
+	for _, v := range []*string{&i.Version, &i.Commit, &i.Branch, &i.BuildDate} {
+		if *v == "" {
+			*v = unknown
+		}
+	}
+	return i
+}
+
+// String returns the build info in the format of the -v flag.
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit: %s, branch: %s, built: %s, runtime: %s)", i.Version, i.Commit, i.Branch, i.BuildDate, i.GoVersion)
+}
+
+// Register sets the build info metric and adds the build info to the patron /info route.
+func Register(i Info) {
+	buildInfo.WithLabelValues(i.Version, i.Commit, i.Branch, i.GoVersion).Set(1)
+	info.UpsertConfig("commit", i.Commit)
+	info.UpsertConfig("branch", i.Branch)
+	info.UpsertConfig("build-date", i.BuildDate)
+}
@@ -0,0 +1,28 @@
+package buildinfo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/beatlabs/patron/sync"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+// Path is the path of the version route, relative to the API group.
+const Path = "/version"
+
+type versionResponse struct {
+	Build Info `json:"build"`
+}
+
+// Spec returns the spec of the GET /version route of the API, returning the build info without the module dependencies.
+func Spec(i Info) route.Spec {
+	i.Deps = nil
+	return route.Spec{
+		Method: http.MethodGet,
+		Path:   Path,
+		Processor: func(context.Context, *sync.Request) (*sync.Response, error) {
+			return sync.NewResponse(versionResponse{Build: i}), nil
+		},
+	}
+}
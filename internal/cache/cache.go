@@ -0,0 +1,216 @@
+// Package cache contains in-memory caches of expensive computations, such as the first page of a feed
+// or a list of suggestions, protected against cache stampedes.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resultHit    = "hit"
+	resultMiss   = "miss"
+	resultShared = "shared"
+
+	defaultMaxEntries  = 10000
+	defaultLoadTimeout = 30 * time.Second
+)
+
+var lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "cache",
+	Name:      "lookups_total",
+	Help:      "Number of cache lookups, by cache and result (hit, miss or shared). The dedup rate is shared / (miss + shared).",
+}, []string{"cache", "result"})
+
+func init() {
+	prometheus.MustRegister(lookups)
+}
+
+// LoadFunc computes the value of a key.
+type LoadFunc func(ctx context.Context) (interface{}, error)
+
+type call struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// Group deduplicates concurrent calls with the same key: the first call computes the value,
+// and the calls arriving while it runs wait for its result instead of computing it again.
+// The zero value is ready to use.
+type Group struct {
+	// Timeout of the computations, defaults to 30 seconds.
+	Timeout time.Duration
+	mu      sync.Mutex
+	calls   map[string]*call
+}
+
+// Do computes the value of the key with load, unless a call for the key is in flight, in which case
+// it waits for the result of that call and returns true. The value is computed with a context detached
+// from the cancellation of the callers, keeping their values, so that a caller going away does not fail
+// the others. Every caller returns early with the error of its own context if it is done.
+func (g *Group) Do(ctx context.Context, key string, load LoadFunc) (interface{}, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, shared := g.calls[key]
+	if !shared {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.load(ctx, key, c, load)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, shared, c.err
+	case <-ctx.Done():
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *Group) load(ctx context.Context, key string, c *call, load LoadFunc) {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer func() {
+		if p := recover(); p != nil {
+			c.val, c.err = nil, errors.Errorf("panic while loading %s: %v", key, p)
+		}
+		cancel()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = load(ctx)
+}
+
+// OptionFunc defines an option func for the cache.
+type OptionFunc func(*Cache) error
+
+// MaxEntries option for setting the maximum number of cached values. When it is reached
+// the expired values are evicted, and if none are expired, arbitrary ones.
+func MaxEntries(n int) OptionFunc {
+	return func(c *Cache) error {
+		if n <= 0 {
+			return errors.New("max entries must be positive")
+		}
+		c.maxEntries = n
+		return nil
+	}
+}
+
+type entry struct {
+	val     interface{}
+	expires time.Time
+}
+
+// Cache caches computed values for a TTL, computing each missing value once even if it is requested
+// concurrently. The keys are scoped by the tenant of the context, and per-user values have to include
+// the user ID in the key. Errors are not cached.
+type Cache struct {
+	mu         sync.Mutex
+	name       string
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]entry
+	group      Group
+}
+
+// New creates a new cache, named in the metrics.
+func New(name string, ttl time.Duration, oo ...OptionFunc) (*Cache, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	c := Cache{
+		name:       name,
+		ttl:        ttl,
+		maxEntries: defaultMaxEntries,
+		entries:    make(map[string]entry),
+	}
+	for _, o := range oo {
+		err := o(&c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Get returns the cached value of the key, or computes and caches it with load.
+func (c *Cache) Get(ctx context.Context, key string, load LoadFunc) (interface{}, error) {
+	key = scoped(ctx, key)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		lookups.WithLabelValues(c.name, resultHit).Inc()
+		return e.val, nil
+	}
+
+	v, shared, err := c.group.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		v, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, v)
+		return v, nil
+	})
+	if shared {
+		lookups.WithLabelValues(c.name, resultShared).Inc()
+	} else {
+		lookups.WithLabelValues(c.name, resultMiss).Inc()
+	}
+	return v, err
+}
+
+// Invalidate removes the cached value of the key, e.g. after a write changing it.
+func (c *Cache) Invalidate(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, scoped(ctx, key))
+}
+
+func (c *Cache) set(key string, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = entry{val: v, expires: time.Now().Add(c.ttl)}
+}
+
+// evict removes the expired entries, or a tenth of the entries if none are expired.
+// It has to be called with the lock held.
+func (c *Cache) evict() {
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < c.maxEntries-c.maxEntries/10 {
+			return
+		}
+		delete(c.entries, k)
+	}
+}
+
+func scoped(ctx context.Context, key string) string {
+	return tenant.ID(ctx) + "\x00" + key
+}
@@ -0,0 +1,180 @@
+// Package config contains the configuration of the service that can be reloaded at runtime.
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+)
+
+// Duration is a time.Duration that is encoded in JSON as a string, e.g. "30s".
+type Duration time.Duration
+
+// UnmarshalJSON decodes a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration must be a string")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// RateLimit configuration of the load shedder.
+type RateLimit struct {
+	MaxInFlight int `json:"maxInFlight"`
+	MaxQueue    int `json:"maxQueue"`
+}
+
+// Cache configuration of the response cache.
+type Cache struct {
+	TTL                  Duration `json:"ttl"`
+	StaleWhileRevalidate Duration `json:"staleWhileRevalidate"`
+}
+
+// Dynamic is the subset of the configuration that can be reloaded without restarting the service.
+type Dynamic struct {
+	// LogLevel is the global log level. It is empty by default, leaving the level of PATRON_LOG_LEVEL.
+	LogLevel  string    `json:"logLevel"`
+	RateLimit RateLimit `json:"rateLimit"`
+	Cache     Cache     `json:"cache"`
+}
+
+// DefaultDynamic returns the default dynamic configuration.
+func DefaultDynamic() Dynamic {
+	return Dynamic{
+		RateLimit: RateLimit{MaxInFlight: 200, MaxQueue: 100},
+		Cache:     Cache{TTL: Duration(10 * time.Second), StaleWhileRevalidate: Duration(time.Minute)},
+	}
+}
+
+// Validate checks the configuration values.
+func (d Dynamic) Validate() error {
+	switch log.Level(d.LogLevel) {
+	case "", log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel, log.FatalLevel, log.PanicLevel:
+	default:
+		return errors.Errorf("invalid log level %q", d.LogLevel)
+	}
+	if d.RateLimit.MaxInFlight <= 0 {
+		return errors.New("rate limit max in flight must be positive")
+	}
+	if d.RateLimit.MaxQueue < 0 {
+		return errors.New("rate limit max queue must not be negative")
+	}
+	if d.Cache.TTL <= 0 {
+		return errors.New("cache ttl must be positive")
+	}
+	if d.Cache.StaleWhileRevalidate < 0 {
+		return errors.New("cache stale while revalidate must not be negative")
+	}
+	return nil
+}
+
+// LoadFile reads the dynamic configuration from a JSON file. Missing values keep their defaults.
+func LoadFile(path string) (Dynamic, error) {
+	d := DefaultDynamic()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return d, errors.Wrapf(err, "failed to read config file %s", path)
+	}
+	err = json.Unmarshal(b, &d)
+	if err != nil {
+		return d, errors.Wrapf(err, "failed to decode config file %s", path)
+	}
+	return d, d.Validate()
+}
+
+// ApplyFunc applies a dynamic configuration to a part of the service.
+type ApplyFunc func(Dynamic) error
+
+// Reloader holds the current dynamic configuration and applies it to its subscribers on every reload.
+type Reloader struct {
+	sync.RWMutex
+	path    string
+	current Dynamic
+	applies []ApplyFunc
+}
+
+// NewReloader creates a new reloader for the config file, loading it once.
+func NewReloader(path string) (*Reloader, error) {
+	if path == "" {
+		return nil, errors.New("config file path is required")
+	}
+	d, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reloader{path: path, current: d}, nil
+}
+
+// Subscribe registers a function applying the configuration, and applies the current configuration to it.
+func (r *Reloader) Subscribe(f ApplyFunc) error {
+	if f == nil {
+		return errors.New("apply func is nil")
+	}
+	r.Lock()
+	defer r.Unlock()
+	err := f(r.current)
+	if err != nil {
+		return err
+	}
+	r.applies = append(r.applies, f)
+	return nil
+}
+
+// Current returns the current configuration.
+func (r *Reloader) Current() Dynamic {
+	r.RLock()
+	defer r.RUnlock()
+	return r.current
+}
+
+// Reload reads the config file again and applies it to all subscribers.
+// If the file is not valid, or a subscriber rejects it, the current configuration is kept
+// and applied again to the subscribers that accepted the new one.
+func (r *Reloader) Reload() error {
+	d, err := LoadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	for i, f := range r.applies {
+		err := f(d)
+		if err == nil {
+			continue
+		}
+		for _, f := range r.applies[:i] {
+			if err := f(r.current); err != nil {
+				log.Errorf("failed to restore config: %v", err)
+			}
+		}
+		return errors.Wrap(err, "failed to apply config")
+	}
+	r.current = d
+	log.Infof("configuration reloaded from %s", r.path)
+	return nil
+}
+
+// SIGHUP returns a handler reloading the configuration, to be used with the patron SIGHUP option.
+func (r *Reloader) SIGHUP() func() {
+	return func() {
+		if err := r.Reload(); err != nil {
+			log.Errorf("failed to reload configuration: %v", err)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// Package db contains helpers for working with SQL databases, such as driver instrumentation.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	opQuery = "query"
+	opExec  = "exec"
+)
+
+var (
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "realworld",
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of SQL queries, by caller method and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "op", "success"})
+	queryRows = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "realworld",
+		Subsystem: "db",
+		Name:      "query_rows",
+		Help:      "Number of rows returned or affected by SQL queries, by caller method and operation.",
+		Buckets:   []float64{0, 1, 5, 10, 20, 50, 100, 500, 1000},
+	}, []string{"method", "op"})
+)
+
+func init() {
+	prometheus.MustRegister(queryDuration, queryRows)
+}
+
+// Register wraps the driver with instrumentation and registers it under the provided name,
+// to be used with sql.Open. Queries slower than the threshold are logged; a zero threshold disables logging.
+func Register(name string, d driver.Driver, slow time.Duration) {
+	sql.Register(name, Wrap(d, slow))
+}
+
+// Wrap returns a driver that records the duration and rows of every query, together with the
+// repository method that issued it, and logs queries slower than the threshold.
+func Wrap(d driver.Driver, slow time.Duration) driver.Driver {
+	return &instrumentedDriver{Driver: d, slow: slow}
+}
+
+type instrumentedDriver struct {
+	driver.Driver
+	slow time.Duration
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, slow: d.slow}, nil
+}
+
+// OpenConnector keeps the connector of drivers implementing driver.DriverContext, which may parse the
+// name once instead of for every connection.
+func (d *instrumentedDriver) OpenConnector(name string) (driver.Connector, error) {
+	dc, ok := d.Driver.(driver.DriverContext)
+	if !ok {
+		return &connector{name: name, d: d}, nil
+	}
+	c, err := dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{Connector: c, d: d}, nil
+}
+
+// connector opens instrumented connections with the connector of the driver, or with its Open.
+type connector struct {
+	driver.Connector
+	name string
+	d    *instrumentedDriver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.Connector == nil {
+		return c.d.Open(c.name)
+	}
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, slow: c.d.slow}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
+
+func (c *connector) Close() error {
+	if cl, ok := c.Connector.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// observation of a single query, finished once the rows are consumed or the statement is executed.
+type observation struct {
+	ctx    context.Context
+	op     string
+	query  string
+	method string
+	start  time.Time
+	slow   time.Duration
+}
+
+func observe(ctx context.Context, op, query string, slow time.Duration) *observation {
+	return &observation{ctx: ctx, op: op, query: query, method: caller(), start: time.Now(), slow: slow}
+}
+
+func (o *observation) finish(rows int64, err error) {
+	d := time.Since(o.start)
+	success := "true"
+	if err != nil {
+		success = "false"
+	}
+	queryDuration.WithLabelValues(o.method, o.op, success).Observe(d.Seconds())
+	if err == nil {
+		queryRows.WithLabelValues(o.method, o.op).Observe(float64(rows))
+	}
+	if o.slow > 0 && d >= o.slow {
+		log.Sub(map[string]interface{}{
+			"method":   o.method,
+			"duration": d.String(),
+			"rows":     rows,
+			"traceID":  tracing.TraceID(o.ctx),
+		}).Warnf("slow query: %s", o.query)
+	}
+}
+
+// caller returns the first function in the call stack outside of database/sql and this package,
+// which is the repository method issuing the query.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "database/sql") && !strings.Contains(f.Function, "/internal/db.") {
+			return f.Function[strings.LastIndex(f.Function, "/")+1:]
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+type conn struct {
+	driver.Conn
+	slow time.Duration
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	st, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query, slow: c.slow}, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	cp, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	st, err := cp.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query, slow: c.slow}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cb, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cb.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	o := observe(ctx, opExec, query, c.slow)
+	res, err := ec.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	o.finish(affected(res, err), err)
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	o := observe(ctx, opQuery, query, c.slow)
+	rr, err := qc.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	if err != nil {
+		o.finish(0, err)
+		return nil, err
+	}
+	return &rows{Rows: rr, o: o}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	slow  time.Duration
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	o := observe(context.Background(), opExec, s.query, s.slow)
+	res, err := s.Stmt.Exec(args)
+	o.finish(affected(res, err), err)
+	return res, err
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	o := observe(context.Background(), opQuery, s.query, s.slow)
+	rr, err := s.Stmt.Query(args)
+	if err != nil {
+		o.finish(0, err)
+		return nil, err
+	}
+	return &rows{Rows: rr, o: o}, nil
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	se, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		vv, err := values(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(vv)
+	}
+	o := observe(ctx, opExec, s.query, s.slow)
+	res, err := se.ExecContext(ctx, args)
+	o.finish(affected(res, err), err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	sq, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		vv, err := values(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(vv)
+	}
+	o := observe(ctx, opQuery, s.query, s.slow)
+	rr, err := sq.QueryContext(ctx, args)
+	if err != nil {
+		o.finish(0, err)
+		return nil, err
+	}
+	return &rows{Rows: rr, o: o}, nil
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// rows counts the rows read and finishes the observation when closed.
+type rows struct {
+	driver.Rows
+	o     *observation
+	count int64
+	err   error
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch err {
+	case nil:
+		r.count++
+	case io.EOF:
+	default:
+		r.err = err
+	}
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	if r.err == nil {
+		r.err = err
+	}
+	r.o.finish(r.count, r.err)
+	return err
+}
+
+// The optional interfaces of the rows are forwarded, falling back to the defaults of database/sql.
+
+func (r *rows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return ct.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return ct.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return ct.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (bool, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return ct.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ct.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+func affected(res driver.Result, err error) int64 {
+	if err != nil || res == nil {
+		return 0
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	vv := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("named arguments are not supported by the driver")
+		}
+		vv[i] = a.Value
+	}
+	return vv, nil
+}
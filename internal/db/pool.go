@@ -0,0 +1,134 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/info"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolConfig definition of the connection pool settings of a database.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPoolConfig returns the default pool settings.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    20,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+	}
+}
+
+// PoolConfigFromEnv returns the pool settings, overriding the defaults with the
+// REALWORLD_DB_MAX_OPEN_CONNS, REALWORLD_DB_MAX_IDLE_CONNS and REALWORLD_DB_CONN_MAX_LIFETIME env vars.
+func PoolConfigFromEnv() (PoolConfig, error) {
+	cfg := DefaultPoolConfig()
+
+	if v, ok := os.LookupEnv("REALWORLD_DB_MAX_OPEN_CONNS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, errors.Wrap(err, "env var for db max open connections is not valid")
+		}
+		cfg.MaxOpenConns = n
+	}
+
+	if v, ok := os.LookupEnv("REALWORLD_DB_MAX_IDLE_CONNS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, errors.Wrap(err, "env var for db max idle connections is not valid")
+		}
+		cfg.MaxIdleConns = n
+	}
+
+	if v, ok := os.LookupEnv("REALWORLD_DB_CONN_MAX_LIFETIME"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, errors.Wrap(err, "env var for db connection max lifetime is not valid")
+		}
+		cfg.ConnMaxLifetime = d
+	}
+
+	return cfg, nil
+}
+
+// ConfigurePool applies the pool settings to the database.
+func ConfigurePool(db *sql.DB, cfg PoolConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	info.UpsertConfig("db-max-open-conns", strconv.Itoa(cfg.MaxOpenConns))
+	info.UpsertConfig("db-max-idle-conns", strconv.Itoa(cfg.MaxIdleConns))
+	info.UpsertConfig("db-conn-max-lifetime", cfg.ConnMaxLifetime.String())
+}
+
+// RegisterPoolMetrics exports the pool statistics of the named database as Prometheus gauges.
+func RegisterPoolMetrics(name string, db *sql.DB) error {
+	if db == nil {
+		return errors.New("db is nil")
+	}
+	return prometheus.Register(newPoolCollector(name, db))
+}
+
+type poolCollector struct {
+	db                *sql.DB
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newPoolCollector(name string, db *sql.DB) *poolCollector {
+	labels := prometheus.Labels{"db": name}
+	desc := func(n, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("realworld", "db_pool", n), help, nil, labels)
+	}
+	return &poolCollector{
+		db:                db,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "Number of established connections, both in use and idle."),
+		inUse:             desc("in_use_connections", "Number of connections currently in use."),
+		idle:              desc("idle_connections", "Number of idle connections."),
+		waitCount:         desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "Total number of connections closed due to the max idle setting."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "Total number of connections closed due to the max lifetime setting."),
+	}
+}
+
+// Describe sends the descriptors of the pool metrics.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect sends the current pool statistics.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(st.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(st.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(st.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(st.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(st.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, st.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(st.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(st.MaxLifetimeClosed))
+}
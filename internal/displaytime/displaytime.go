@@ -0,0 +1,79 @@
+// Package displaytime adds localized display timestamps to responses, next to the ISO 8601 UTC
+// timestamps required by the RealWorld spec, for frontends that do not want to convert time zones.
+package displaytime
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const (
+	// TimezoneHeader is the header requesting display timestamps in an IANA time zone, e.g. Europe/Athens.
+	TimezoneHeader = "X-Display-Timezone"
+	// TimezoneParam is the query parameter requesting display timestamps, taking precedence over the header.
+	TimezoneParam = "tz"
+	// ProfileTimezone is the value of the header or parameter selecting the time zone stored on the profile of the user.
+	ProfileTimezone = "profile"
+)
+
+// layouts are the display layouts per language. Go formats month names in English only, so other
+// languages use numeric dates.
+var layouts = map[string]string{
+	"en": "Jan 2, 2006 3:04 PM MST",
+	"de": "02.01.2006 15:04 MST",
+	"es": "02/01/2006 15:04 MST",
+	"fr": "02/01/2006 15:04 MST",
+}
+
+const defaultLayout = "2006-01-02 15:04 MST"
+
+// Formatter formats timestamps for display in a time zone and language.
+type Formatter struct {
+	loc    *time.Location
+	layout string
+}
+
+// New creates a new formatter for the IANA time zone and the language, e.g. de.
+func New(tz, lang string) (*Formatter, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid time zone %q", tz)
+	}
+	l, ok := layouts[strings.ToLower(lang)]
+	if !ok {
+		l = defaultLayout
+	}
+	return &Formatter{loc: loc, layout: l}, nil
+}
+
+// FromRequest creates a formatter if the request asks for display timestamps with the tz query parameter
+// or the X-Display-Timezone header. The value "profile" selects profileTZ, the time zone preference
+// of the authenticated user, if any. It returns nil if no display timestamps are requested.
+func FromRequest(r *http.Request, lang, profileTZ string) (*Formatter, error) {
+	tz := r.URL.Query().Get(TimezoneParam)
+	if tz == "" {
+		tz = r.Header.Get(TimezoneHeader)
+	}
+	if tz == "" {
+		return nil, nil
+	}
+	if tz == ProfileTimezone {
+		if profileTZ == "" {
+			return nil, nil
+		}
+		tz = profileTZ
+	}
+	return New(tz, lang)
+}
+
+// Format formats the timestamp for display. A nil formatter returns an empty string,
+// so that the display fields can be omitted from the envelopes with omitempty.
+func (f *Formatter) Format(t time.Time) string {
+	if f == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(f.loc).Format(f.layout)
+}
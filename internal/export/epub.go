@@ -0,0 +1,133 @@
+// Package export renders articles to files for offline reading.
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path"
+	"text/template"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// EPUBContentType is the content type of EPUB files.
+const EPUBContentType = "application/epub+zip"
+
+// Article to be exported.
+type Article struct {
+	Slug      string
+	Title     string
+	Author    string
+	Body      string
+	Language  string
+	UpdatedAt time.Time
+}
+
+var epubTemplates = template.Must(template.New("container.xml").Funcs(template.FuncMap{"xml": escape}).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+func init() {
+	template.Must(epubTemplates.New("content.opf").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:realworld:article:{{xml .Slug}}</dc:identifier>
+    <dc:title>{{xml .Title}}</dc:title>
+    <dc:creator>{{xml .Author}}</dc:creator>
+    <dc:language>{{xml .Language}}</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="article" href="article.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="article"/>
+  </spine>
+</package>
+`))
+	template.Must(epubTemplates.New("nav.xhtml").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>{{xml .Title}}</title></head>
+<body>
+  <nav epub:type="toc"><ol><li><a href="article.xhtml">{{xml .Title}}</a></li></ol></nav>
+</body>
+</html>
+`))
+	template.Must(epubTemplates.New("article.xhtml").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{xml .Language}}">
+<head><title>{{xml .Title}}</title></head>
+<body>
+<h1>{{xml .Title}}</h1>
+<p>{{xml .Author}}</p>
+{{.HTML}}
+</body>
+</html>
+`))
+}
+
+// epubFiles are the files of the book in archive order, each rendered by the template with its base name.
+var epubFiles = []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/article.xhtml"}
+
+type epubData struct {
+	Article
+	Modified string
+	HTML     string
+}
+
+// WriteEPUB writes the article as an EPUB 3 book with a single chapter.
+// Raw HTML in the markdown is skipped, so that the chapter is always valid XHTML.
+func WriteEPUB(w io.Writer, a Article) error {
+	if a.Language == "" {
+		a.Language = "en"
+	}
+	r := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: blackfriday.UseXHTML | blackfriday.SkipHTML})
+	d := epubData{
+		Article:  a,
+		Modified: a.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		HTML:     string(blackfriday.Run([]byte(a.Body), blackfriday.WithRenderer(r))),
+	}
+
+	zw := zip.NewWriter(w)
+	// the mimetype has to be the first entry of the archive and must not be compressed.
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return errors.Wrap(err, "failed to write epub")
+	}
+	if _, err := io.WriteString(mw, EPUBContentType); err != nil {
+		return errors.Wrap(err, "failed to write epub")
+	}
+	for _, name := range epubFiles {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return errors.Wrap(err, "failed to write epub")
+		}
+		if err := epubTemplates.ExecuteTemplate(fw, path.Base(name), d); err != nil {
+			return errors.Wrapf(err, "failed to render %s", name)
+		}
+	}
+	return zw.Close()
+}
+
+func escape(s string) (string, error) {
+	var b stringWriter
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type stringWriter []byte
+
+func (w *stringWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
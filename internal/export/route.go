@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Path is the path of the export route.
+const Path = "/api/articles/:slug/export"
+
+const (
+	// FormatEPUB exports the article as an EPUB book.
+	FormatEPUB = "epub"
+	// FormatPDF exports the article as a PDF document.
+	FormatPDF = "pdf"
+)
+
+// ErrNotFound is returned by sources when the article does not exist.
+var ErrNotFound = errors.New("article not found")
+
+// Source of the exported articles.
+type Source interface {
+	Article(ctx context.Context, slug string) (Article, error)
+}
+
+// Route creates the GET /api/articles/:slug/export?format= route, returning the article as a file.
+// Only the epub format is available, pdf is rejected since there is no PDF renderer.
+func Route(src Source) patronhttp.Route {
+	return patronhttp.NewRouteRaw(Path, http.MethodGet, handler(src), true)
+}
+
+func handler(src Source) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch f := r.URL.Query().Get("format"); f {
+		case FormatEPUB, "":
+		case FormatPDF:
+			apierror.Write(w, http.StatusUnprocessableEntity, "format pdf is not available")
+			return
+		default:
+			apierror.Write(w, http.StatusUnprocessableEntity, "format must be one of epub, pdf")
+			return
+		}
+
+		slug := httprouter.ParamsFromContext(r.Context()).ByName("slug")
+		a, err := src.Article(r.Context(), slug)
+		if err == ErrNotFound {
+			apierror.Write(w, http.StatusNotFound, "article not found")
+			return
+		}
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("failed to get article %s: %v", slug, err)
+			apierror.Write(w, http.StatusInternalServerError, "failed to export article")
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := WriteEPUB(&buf, a); err != nil {
+			log.FromContext(r.Context()).Errorf("failed to export article %s: %v", slug, err)
+			apierror.Write(w, http.StatusInternalServerError, "failed to export article")
+			return
+		}
+		w.Header().Set("Content-Type", EPUBContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+a.Slug+`.epub"`)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			log.FromContext(r.Context()).Errorf("failed to write export: %v", err)
+		}
+	}
+}
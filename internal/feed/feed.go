@@ -0,0 +1,182 @@
+// Package feed publishes the articles as RSS 2.0 and Atom feeds.
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	// RSSContentType is the content type of RSS feeds.
+	RSSContentType = "application/rss+xml; charset=utf-8"
+	// AtomContentType is the content type of Atom feeds.
+	AtomContentType = "application/atom+xml; charset=utf-8"
+
+	// DefaultLimit is the number of articles in a feed.
+	DefaultLimit = 20
+)
+
+// Item is an article of a feed.
+type Item struct {
+	Slug        string
+	Title       string
+	Description string
+	Link        string
+	Author      string
+	Tags        []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Filter of the articles of a feed. Empty fields do not filter.
+type Filter struct {
+	Tag    string
+	Author string
+	Limit  int
+}
+
+// Source interface for getting the latest published articles, newest first.
+type Source interface {
+	Articles(ctx context.Context, f Filter) ([]Item, error)
+}
+
+// Channel describes a feed.
+type Channel struct {
+	Title       string
+	Link        string
+	Description string
+}
+
+// dcNamespace is the Dublin Core namespace. RSS author elements must be email addresses,
+// so the author usernames are set as dc:creator instead.
+const dcNamespace = "http://purl.org/dc/elements/1.1/"
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes the items as an RSS 2.0 feed.
+func WriteRSS(w io.Writer, ch Channel, ii []Item) error {
+	f := rss{Version: "2.0", DC: dcNamespace, Channel: rssChannel{Title: ch.Title, Link: ch.Link, Description: ch.Description}}
+	if len(ii) > 0 {
+		f.Channel.LastBuildDate = Updated(ii).Format(time.RFC1123Z)
+	}
+	for _, i := range ii {
+		f.Channel.Items = append(f.Channel.Items, rssItem{
+			Title:       i.Title,
+			Link:        i.Link,
+			Description: i.Description,
+			Creator:     i.Author,
+			Categories:  i.Tags,
+			GUID:        rssGUID{IsPermaLink: true, Value: i.Link},
+			PubDate:     i.CreatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return write(w, f)
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Summary    string         `xml:"summary"`
+	Author     atomAuthor     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteAtom writes the items as an Atom feed. A feed without items is updated at the current time,
+// since Atom requires the element.
+func WriteAtom(w io.Writer, ch Channel, ii []Item) error {
+	updated := Updated(ii)
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+	f := atom{Title: ch.Title, ID: ch.Link, Link: atomLink{Href: ch.Link}, Updated: updated.Format(time.RFC3339)}
+	for _, i := range ii {
+		e := atomEntry{
+			Title:     i.Title,
+			ID:        i.Link,
+			Link:      atomLink{Href: i.Link},
+			Summary:   i.Description,
+			Author:    atomAuthor{Name: i.Author},
+			Published: i.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   i.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		for _, t := range i.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return write(w, f)
+}
+
+// Updated returns the latest update time of the items, or the zero time if there are none.
+func Updated(ii []Item) time.Time {
+	var t time.Time
+	for _, i := range ii {
+		if i.UpdatedAt.After(t) {
+			t = i.UpdatedAt
+		}
+	}
+	return t.UTC()
+}
+
+func write(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
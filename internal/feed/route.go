@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+// Path is the path of the feed route.
+const Path = "/api/articles/rss"
+
+// MaxAge is the time the feeds may be cached by clients and proxies.
+const MaxAge = 5 * time.Minute
+
+// Route creates the GET /api/articles/rss route, serving the latest articles as an RSS feed,
+// or as an Atom feed with ?format=atom. The feed can be filtered with ?tag= and ?author=.
+func Route(ch Channel, src Source) patronhttp.Route {
+	return patronhttp.NewRouteRaw(Path, http.MethodGet, handler(ch, src), true)
+}
+
+func handler(ch Channel, src Source) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := Filter{Tag: q.Get("tag"), Author: q.Get("author"), Limit: DefaultLimit}
+		ii, err := src.Articles(r.Context(), f)
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("failed to get feed articles: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		updated := Updated(ii)
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(MaxAge/time.Second)))
+		if !updated.IsZero() {
+			w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+			if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !updated.Truncate(time.Second).After(ims) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		c := ch
+		if f.Tag != "" {
+			c.Title += " - " + f.Tag
+		}
+		if f.Author != "" {
+			c.Title += " - " + f.Author
+		}
+
+		var buf bytes.Buffer
+		ct := RSSContentType
+		if q.Get("format") == "atom" {
+			ct = AtomContentType
+			err = WriteAtom(&buf, c, ii)
+		} else {
+			err = WriteRSS(&buf, c, ii)
+		}
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("failed to encode feed: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ct)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			log.FromContext(r.Context()).Errorf("failed to write feed: %v", err)
+		}
+	}
+}
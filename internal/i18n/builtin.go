@@ -0,0 +1,31 @@
+package i18n
+
+// builtin holds the translations shipped with the service.
+var builtin = map[string]map[string]string{
+	"de": {
+		"body is not valid":                             "Der Inhalt ist ungültig",
+		"request body is empty":                         "Der Inhalt der Anfrage ist leer",
+		"request body too large":                        "Der Inhalt der Anfrage ist zu groß",
+		"request body must contain a single JSON value": "Der Inhalt der Anfrage muss genau einen JSON-Wert enthalten",
+		"request body is not valid JSON: {}":            "Der Inhalt der Anfrage ist kein gültiges JSON: {}",
+		"unknown field {}":                              "Unbekanntes Feld {}",
+		"request timed out":                             "Zeitüberschreitung der Anfrage",
+		"service overloaded":                            "Der Dienst ist überlastet",
+		"unknown tenant":                                "Unbekannter Mandant",
+		"{} contains inappropriate language":            "{} enthält unangemessene Sprache",
+		"password has appeared in a data breach, choose a different password": "Das Passwort ist in einem Datenleck aufgetaucht, bitte ein anderes wählen",
+	},
+	"es": {
+		"body is not valid":                             "El cuerpo no es válido",
+		"request body is empty":                         "El cuerpo de la solicitud está vacío",
+		"request body too large":                        "El cuerpo de la solicitud es demasiado grande",
+		"request body must contain a single JSON value": "El cuerpo de la solicitud debe contener un único valor JSON",
+		"request body is not valid JSON: {}":            "El cuerpo de la solicitud no es JSON válido: {}",
+		"unknown field {}":                              "Campo desconocido {}",
+		"request timed out":                             "La solicitud ha excedido el tiempo de espera",
+		"service overloaded":                            "El servicio está sobrecargado",
+		"unknown tenant":                                "Inquilino desconocido",
+		"{} contains inappropriate language":            "{} contiene lenguaje inapropiado",
+		"password has appeared in a data breach, choose a different password": "La contraseña ha aparecido en una filtración de datos, elija otra",
+	},
+}
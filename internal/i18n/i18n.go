@@ -0,0 +1,168 @@
+// Package i18n translates the error and validation messages of the API to the language of the client.
+// Messages are written in English in the code and used as the keys of the catalogs, so English is always the fallback.
+package i18n
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+)
+
+// DefaultLanguage is the language of the messages in the code.
+const DefaultLanguage = "en"
+
+// Placeholder marks the variable parts of a message in the catalog keys and translations,
+// e.g. "unknown field {}" translated as "unbekanntes Feld {}".
+const Placeholder = "{}"
+
+type entry struct {
+	pattern     *regexp.Regexp
+	translation string
+}
+
+// Catalog holds the translations of the messages per language.
+type Catalog struct {
+	sync.RWMutex
+	exact    map[string]map[string]string
+	patterns map[string][]entry
+}
+
+// NewCatalog creates a new catalog with the built-in translations.
+func NewCatalog() *Catalog {
+	c := Catalog{exact: make(map[string]map[string]string), patterns: make(map[string][]entry)}
+	for lang, mm := range builtin {
+		c.Add(lang, mm)
+	}
+	return &c
+}
+
+// LoadDir adds the translations of the <language>.json files of the directory, e.g. fr.json,
+// each holding an object from English messages to their translations.
+// It allows adding languages without code changes.
+func (c *Catalog) LoadDir(dir string) error {
+	ff, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list catalogs")
+	}
+	for _, f := range ff {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read catalog %s", f)
+		}
+		mm := make(map[string]string)
+		if err := json.Unmarshal(b, &mm); err != nil {
+			return errors.Wrapf(err, "failed to decode catalog %s", f)
+		}
+		lang := strings.TrimSuffix(filepath.Base(f), ".json")
+		c.Add(lang, mm)
+		log.Infof("loaded %d messages for language %s", len(mm), lang)
+	}
+	return nil
+}
+
+// Add adds translations of the language, replacing existing ones. The added patterns are matched before the
+// existing ones, so that the catalogs loaded at startup override the built-in translations.
+func (c *Catalog) Add(lang string, mm map[string]string) {
+	lang = strings.ToLower(lang)
+	c.Lock()
+	defer c.Unlock()
+	if c.exact[lang] == nil {
+		c.exact[lang] = make(map[string]string)
+	}
+	var added []entry
+	replaced := make(map[string]bool)
+	for msg, tr := range mm {
+		if !strings.Contains(msg, Placeholder) {
+			c.exact[lang][msg] = tr
+			continue
+		}
+		parts := strings.Split(msg, Placeholder)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		p := regexp.MustCompile("^" + strings.Join(parts, "(.*?)") + "$")
+		added = append(added, entry{pattern: p, translation: tr})
+		replaced[p.String()] = true
+	}
+	for _, e := range c.patterns[lang] {
+		if !replaced[e.pattern.String()] {
+			added = append(added, e)
+		}
+	}
+	c.patterns[lang] = added
+}
+
+// Languages returns the languages of the catalog, including the default language.
+func (c *Catalog) Languages() []string {
+	c.RLock()
+	defer c.RUnlock()
+	ll := []string{DefaultLanguage}
+	for l := range c.exact {
+		if l != DefaultLanguage {
+			ll = append(ll, l)
+		}
+	}
+	sort.Strings(ll[1:])
+	return ll
+}
+
+// Translate returns the translation of the message to the language, or the message if there is none.
+func (c *Catalog) Translate(lang, msg string) string {
+	c.RLock()
+	defer c.RUnlock()
+	if tr, ok := c.exact[lang][msg]; ok {
+		return tr
+	}
+	for _, e := range c.patterns[lang] {
+		m := e.pattern.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		tr := e.translation
+		for _, v := range m[1:] {
+			tr = strings.Replace(tr, Placeholder, v, 1)
+		}
+		return tr
+	}
+	return msg
+}
+
+// Negotiate returns the language of the catalog best matching the Accept-Language header,
+// or the default language.
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	c.RLock()
+	defer c.RUnlock()
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				v, err := strconv.ParseFloat(p[2:], 64)
+				if err == nil {
+					q = v
+				}
+			}
+		}
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if q <= bestQ {
+			continue
+		}
+		if _, ok := c.exact[tag]; ok || tag == DefaultLanguage {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,64 @@
+package jwks
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/beatlabs/patron/sync"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+const (
+	// Prefix is the well-known prefix the key set is served under.
+	Prefix = "/.well-known"
+	// Path is the path of the key set, relative to the well-known prefix.
+	Path = "/jwks.json"
+)
+
+// Key is a JSON Web Key of an RSA public key, see RFC 7517.
+type Key struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Document is a JSON Web Key Set.
+type Document struct {
+	Keys []Key `json:"keys"`
+}
+
+// Document returns the public keys of the set, so that other services can validate the tokens.
+func (ks *KeySet) Document() Document {
+	ks.RLock()
+	defer ks.RUnlock()
+	d := Document{Keys: make([]Key, 0, len(ks.keys))}
+	for kid, k := range ks.keys {
+		d.Keys = append(d.Keys, Key{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		})
+	}
+	sort.Slice(d.Keys, func(i, j int) bool { return d.Keys[i].Kid < d.Keys[j].Kid })
+	return d
+}
+
+// Spec returns the spec of the GET /jwks.json route, to be served under the well-known prefix.
+func Spec(ks *KeySet) route.Spec {
+	return route.Spec{
+		Method: http.MethodGet,
+		Path:   Path,
+		Processor: func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+			return sync.NewResponse(ks.Document()), nil
+		},
+	}
+}
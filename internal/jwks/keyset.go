@@ -0,0 +1,115 @@
+// Package jwks manages the RSA keys signing the JWT tokens and publishes their public parts as a JSON Web Key Set.
+package jwks
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+)
+
+// KeySet holds the signing keys loaded from a directory, identified by their kid.
+//
+// Every <kid>.pem file of the directory holds a PKCS#1 or PKCS#8 RSA private key.
+// New tokens are signed with the key whose kid sorts last, so keys are rotated by adding
+// a key named after the current date, e.g. 2019-06-01.pem, and reloading.
+// Retired keys stay in the directory, and in the published key set, until the
+// tokens they signed have expired, and are then removed.
+type KeySet struct {
+	sync.RWMutex
+	dir    string
+	keys   map[string]*rsa.PrivateKey
+	active string
+}
+
+// NewKeySet creates a new key set, loading the keys of the directory.
+func NewKeySet(dir string) (*KeySet, error) {
+	if dir == "" {
+		return nil, errors.New("key directory is required")
+	}
+	ks := KeySet{dir: dir}
+	err := ks.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &ks, nil
+}
+
+// Load reads the keys of the directory again. If any key is not valid the current keys are kept.
+func (ks *KeySet) Load() error {
+	ff, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list key files")
+	}
+	if len(ff) == 0 {
+		return errors.Errorf("no key files found in %s", ks.dir)
+	}
+
+	keys := make(map[string]*rsa.PrivateKey, len(ff))
+	kids := make([]string, 0, len(ff))
+	for _, f := range ff {
+		k, err := readKey(f)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(f), ".pem")
+		keys[kid] = k
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	ks.Lock()
+	defer ks.Unlock()
+	ks.keys = keys
+	ks.active = kids[len(kids)-1]
+	log.Infof("loaded %d signing keys, active key is %s", len(kids), ks.active)
+	return nil
+}
+
+// Active returns the kid and the key signing new tokens.
+func (ks *KeySet) Active() (string, *rsa.PrivateKey) {
+	ks.RLock()
+	defer ks.RUnlock()
+	return ks.active, ks.keys[ks.active]
+}
+
+// Public returns the public key of the kid, for validating tokens.
+func (ks *KeySet) Public(kid string) (*rsa.PublicKey, bool) {
+	ks.RLock()
+	defer ks.RUnlock()
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, false
+	}
+	return &k.PublicKey, true
+}
+
+func readKey(file string) (*rsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key file %s", file)
+	}
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		return nil, errors.Errorf("key file %s is not PEM encoded", file)
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(blk.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(blk.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse key file %s", file)
+	}
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("key file %s does not hold an RSA key", file)
+	}
+	return rk, nil
+}
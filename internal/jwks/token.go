@@ -0,0 +1,139 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+// scheme of the Authorization header carrying a user token, as in the RealWorld API spec.
+const scheme = "Token "
+
+// ErrInvalidToken is returned if a token is malformed, expired or not signed by a key of the set.
+var ErrInvalidToken = errors.New("token is not valid")
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Sub string `json:"sub"`
+	Exp int64  `json:"exp"`
+	Nbf int64  `json:"nbf"`
+}
+
+// Verify returns the subject, i.e. the user ID, of an RS256 token signed by a key of the set.
+// The token has to expire, so that the tokens of a retired key stop being valid.
+func (ks *KeySet) Verify(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+	var h tokenHeader
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != "RS256" {
+		return "", ErrInvalidToken
+	}
+	k, ok := ks.Public(h.Kid)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig); err != nil {
+		return "", ErrInvalidToken
+	}
+	var c tokenClaims
+	if err := decodeSegment(parts[1], &c); err != nil || c.Sub == "" || c.Exp == 0 {
+		return "", ErrInvalidToken
+	}
+	if now.Unix() >= c.Exp || now.Unix() < c.Nbf {
+		return "", ErrInvalidToken
+	}
+	return c.Sub, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+type userKey struct{}
+
+// UserID returns the ID of the user authenticated by the token of the request of the context.
+func UserID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userKey{}).(string)
+	return id, ok
+}
+
+// Middleware creates a MiddlewareFunc adding the user of a valid `Authorization: Token ...` header to the context.
+// Requests without a valid token are served unchanged, so that the authenticator can reject them.
+func Middleware(ks *KeySet) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := fromRequest(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			id, err := ks.Verify(token, time.Now())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, id)))
+		})
+	}
+}
+
+// Authenticator authenticates the requests of users carrying a valid `Authorization: Token ...` header.
+// It implements the patron auth.Authenticator interface.
+type Authenticator struct {
+	keys *KeySet
+}
+
+// NewAuthenticator creates a new authenticator of the tokens signed by the key set.
+func NewAuthenticator(ks *KeySet) (*Authenticator, error) {
+	if ks == nil {
+		return nil, errors.New("key set is nil")
+	}
+	return &Authenticator{keys: ks}, nil
+}
+
+// Authenticate returns true if the request carries a valid token. Users already found by the
+// middleware are not verified again.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	if _, ok := UserID(req.Context()); ok {
+		return true, nil
+	}
+	token, ok := fromRequest(req)
+	if !ok {
+		return false, nil
+	}
+	_, err := a.keys.Verify(token, time.Now())
+	return err == nil, nil
+}
+
+func fromRequest(req *http.Request) (string, bool) {
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, scheme) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(h, scheme))
+	return token, token != ""
+}
@@ -0,0 +1,113 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newKeySet(t *testing.T, kid string) (*KeySet, *rsa.PrivateKey) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	dir := t.TempDir()
+	p := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+	if err := ioutil.WriteFile(filepath.Join(dir, kid+".pem"), p, 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	ks, err := NewKeySet(dir)
+	if err != nil {
+		t.Fatalf("NewKeySet() error: %v", err)
+	}
+	return ks, k
+}
+
+func sign(t *testing.T, k *rsa.PrivateKey, header, claims interface{}) string {
+	t.Helper()
+	seg := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to encode token: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := seg(header) + "." + seg(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ks, k := newKeySet(t, "2023-11-01")
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	header := tokenHeader{Alg: "RS256", Kid: "2023-11-01"}
+	valid := tokenClaims{Sub: "user-1", Exp: now.Add(time.Hour).Unix()}
+
+	tests := map[string]struct {
+		token string
+		want  string
+	}{
+		"valid":          {token: sign(t, k, header, valid), want: "user-1"},
+		"expired":        {token: sign(t, k, header, tokenClaims{Sub: "user-1", Exp: now.Unix()})},
+		"not yet valid":  {token: sign(t, k, header, tokenClaims{Sub: "user-1", Exp: valid.Exp, Nbf: now.Add(time.Minute).Unix()})},
+		"without expiry": {token: sign(t, k, header, tokenClaims{Sub: "user-1"})},
+		"without user":   {token: sign(t, k, header, tokenClaims{Exp: valid.Exp})},
+		"unknown kid":    {token: sign(t, k, tokenHeader{Alg: "RS256", Kid: "2023-10-01"}, valid)},
+		"other key":      {token: sign(t, other, header, valid)},
+		"other alg":      {token: sign(t, k, tokenHeader{Alg: "none", Kid: "2023-11-01"}, valid)},
+		"malformed":      {token: "a.b"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ks.Verify(tt.token, now)
+			if tt.want == "" {
+				if err != ErrInvalidToken {
+					t.Errorf("Verify() = %s, %v, want %v", got, err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Verify() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ks, k := newKeySet(t, "2023-11-01")
+	token := sign(t, k, tokenHeader{Alg: "RS256", Kid: "2023-11-01"}, tokenClaims{Sub: "user-1", Exp: time.Now().Add(time.Hour).Unix()})
+
+	var user string
+	h := Middleware(ks)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = UserID(r.Context())
+	}))
+	for auth, want := range map[string]string{"Token " + token: "user-1", "Token invalid": "", "": ""} {
+		user = ""
+		req := httptest.NewRequest(http.MethodGet, "/api/user/api-keys", nil)
+		req.Header.Set("Authorization", auth)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if user != want {
+			t.Errorf("user of Authorization %q = %q, want %q", auth, user, want)
+		}
+	}
+}
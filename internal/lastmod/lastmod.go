@@ -0,0 +1,112 @@
+// Package lastmod tracks when the article collections last changed, so that list endpoints
+// can answer conditional GET requests with a 304 instead of the unchanged page.
+package lastmod
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// Global is the key of the collection of all articles.
+const Global = "articles"
+
+// Tag returns the key of the collection of the articles with the tag.
+func Tag(tag string) string {
+	return "tag:" + tag
+}
+
+// Author returns the key of the collection of the articles of the author.
+func Author(username string) string {
+	return "author:" + username
+}
+
+// Favorited returns the key of the collection of the articles favorited by the user.
+func Favorited(username string) string {
+	return "favorited:" + username
+}
+
+// Store interface for persisting the last modification time of the collections.
+type Store interface {
+	// Touch sets the last modification time of the collections, unless it is already later.
+	Touch(ctx context.Context, t time.Time, keys ...string) error
+	// Get returns the last modification time of the collections, or zero times for unknown ones.
+	Get(ctx context.Context, keys ...string) ([]time.Time, error)
+}
+
+// Change describes a change of an article for ArticleChanged.
+type Change struct {
+	Author string
+	// Tags of the article, both before and after the change, since the article leaves the collections of removed tags.
+	Tags []string
+	// FavoritedBy are the users whose favorites changed.
+	FavoritedBy []string
+}
+
+// ArticleChanged touches the collections containing the article. It has to be called on every change of
+// an article appearing in lists, including favorites, since the lists contain the favorites count,
+// with the author and tags of the article so that the filtered lists change too.
+func ArticleChanged(ctx context.Context, s Store, t time.Time, c Change) error {
+	keys := []string{Global}
+	if c.Author != "" {
+		keys = append(keys, Author(c.Author))
+	}
+	for _, tag := range c.Tags {
+		keys = append(keys, Tag(tag))
+	}
+	for _, u := range c.FavoritedBy {
+		keys = append(keys, Favorited(u))
+	}
+	return errors.Wrap(s.Touch(ctx, t.UTC().Truncate(time.Second), keys...), "failed to touch collections")
+}
+
+// Latest returns the latest last modification time of the collections.
+func Latest(ctx context.Context, s Store, keys ...string) (time.Time, error) {
+	tt, err := s.Get(ctx, keys...)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to get collection modification times")
+	}
+	var latest time.Time
+	for _, t := range tt {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, nil
+}
+
+// MemoryStore is an in-memory implementation of the Store.
+type MemoryStore struct {
+	sync.RWMutex
+	times map[string]time.Time
+}
+
+// NewMemoryStore creates a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{times: make(map[string]time.Time)}
+}
+
+// Touch sets the last modification time of the collections, unless it is already later.
+func (s *MemoryStore) Touch(_ context.Context, t time.Time, keys ...string) error {
+	s.Lock()
+	defer s.Unlock()
+	for _, k := range keys {
+		if t.After(s.times[k]) {
+			s.times[k] = t
+		}
+	}
+	return nil
+}
+
+// Get returns the last modification time of the collections.
+func (s *MemoryStore) Get(_ context.Context, keys ...string) ([]time.Time, error) {
+	s.RLock()
+	defer s.RUnlock()
+	tt := make([]time.Time, len(keys))
+	for i, k := range keys {
+		tt[i] = s.times[k]
+	}
+	return tt, nil
+}
@@ -0,0 +1,65 @@
+package lastmod
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+// KeysFunc returns the keys of the collections a list request reads.
+type KeysFunc func(r *http.Request) []string
+
+// ArticleListKeys returns the keys of GET /api/articles, selected by its tag, author and favorited filters,
+// or the global key if it is not filtered.
+func ArticleListKeys(r *http.Request) []string {
+	q := r.URL.Query()
+	var keys []string
+	if v := q.Get("tag"); v != "" {
+		keys = append(keys, Tag(v))
+	}
+	if v := q.Get("author"); v != "" {
+		keys = append(keys, Author(v))
+	}
+	if v := q.Get("favorited"); v != "" {
+		keys = append(keys, Favorited(v))
+	}
+	if len(keys) == 0 {
+		keys = append(keys, Global)
+	}
+	return keys
+}
+
+// Middleware creates a MiddlewareFunc setting the Last-Modified header of a list endpoint,
+// and responding with a 304 if the collections have not changed since If-Modified-Since.
+// Authenticated requests are passed through, since their lists also depend on the follows
+// and favorites of the user. Since Last-Modified has a precision of a second, it is only set once
+// the second of the last modification is over, as a later change in the same second would not be told apart.
+func Middleware(s Store, keys KeysFunc) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.Header.Get("Authorization") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			modified, err := Latest(r.Context(), s, keys(r)...)
+			if err != nil {
+				log.FromContext(r.Context()).Errorf("failed to get last modified: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if modified.IsZero() || !time.Now().Truncate(time.Second).After(modified) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+			w.Header().Add("Vary", "Authorization")
+			if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.Truncate(time.Second).After(ims) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
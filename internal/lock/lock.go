@@ -0,0 +1,130 @@
+// Package lock provides distributed locks, used to ensure that only one replica of the service
+// runs singleton work such as scheduled jobs.
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrLost is returned when renewing a lock that is no longer held.
+	ErrLost = errors.New("lock lost")
+
+	acquisitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "realworld",
+		Subsystem: "lock",
+		Name:      "acquisitions_total",
+		Help:      "Number of lock acquisition attempts, by key and result.",
+	}, []string{"key", "result"})
+	held = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "realworld",
+		Subsystem: "lock",
+		Name:      "held",
+		Help:      "Whether the lock is held by this instance, by key.",
+	}, []string{"key"})
+	renewals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "realworld",
+		Subsystem: "lock",
+		Name:      "renewals_total",
+		Help:      "Number of lock renewals, by key and result.",
+	}, []string{"key", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(acquisitions, held, renewals)
+}
+
+// Locker interface for acquiring locks without blocking.
+type Locker interface {
+	// TryAcquire returns the lock and true if it was acquired, or false if it is held by someone else.
+	TryAcquire(ctx context.Context, key string) (Lock, bool, error)
+}
+
+// Lock interface of an acquired lock.
+type Lock interface {
+	// Renew extends the lock or verifies that it is still held. It returns ErrLost if it is not.
+	Renew(ctx context.Context) error
+	// Release releases the lock.
+	Release(ctx context.Context) error
+}
+
+// Elector elects a leader among the replicas by holding a lock. The lock is renewed at most
+// once per renewal interval while this instance is the leader. It satisfies the scheduler Elector interface.
+type Elector struct {
+	sync.Mutex
+	locker        Locker
+	key           string
+	renewInterval time.Duration
+	lock          Lock
+	renewedAt     time.Time
+}
+
+// NewElector creates a new elector for the lock key.
+func NewElector(l Locker, key string, renewInterval time.Duration) (*Elector, error) {
+	if l == nil {
+		return nil, errors.New("locker is nil")
+	}
+	if key == "" {
+		return nil, errors.New("key is required")
+	}
+	if renewInterval <= 0 {
+		return nil, errors.New("renew interval must be positive")
+	}
+	return &Elector{locker: l, key: key, renewInterval: renewInterval}, nil
+}
+
+// Leader returns true if this instance holds the leader lock, trying to acquire it if not.
+func (e *Elector) Leader(ctx context.Context) (bool, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.lock != nil {
+		if time.Since(e.renewedAt) < e.renewInterval {
+			return true, nil
+		}
+		err := e.lock.Renew(ctx)
+		if err == nil {
+			renewals.WithLabelValues(e.key, "success").Inc()
+			e.renewedAt = time.Now()
+			return true, nil
+		}
+		renewals.WithLabelValues(e.key, "failure").Inc()
+		log.Warnf("leader lock %s lost: %v", e.key, err)
+		e.lock = nil
+		held.WithLabelValues(e.key).Set(0)
+	}
+
+	l, ok, err := e.locker.TryAcquire(ctx, e.key)
+	if err != nil {
+		acquisitions.WithLabelValues(e.key, "error").Inc()
+		return false, errors.Wrapf(err, "failed to acquire lock %s", e.key)
+	}
+	if !ok {
+		acquisitions.WithLabelValues(e.key, "contended").Inc()
+		return false, nil
+	}
+	acquisitions.WithLabelValues(e.key, "acquired").Inc()
+	held.WithLabelValues(e.key).Set(1)
+	e.lock = l
+	e.renewedAt = time.Now()
+	return true, nil
+}
+
+// Resign releases the leader lock if held.
+func (e *Elector) Resign(ctx context.Context) error {
+	e.Lock()
+	defer e.Unlock()
+	if e.lock == nil {
+		return nil
+	}
+	err := e.lock.Release(ctx)
+	e.lock = nil
+	held.WithLabelValues(e.key).Set(0)
+	return err
+}
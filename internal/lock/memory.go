@@ -0,0 +1,61 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// MemoryLocker is an in-process locker with expiring leases, for single instance deployments.
+type MemoryLocker struct {
+	sync.Mutex
+	ttl    time.Duration
+	leases map[string]*memoryLock
+}
+
+// NewMemoryLocker creates a new in-process locker whose locks expire after the TTL unless renewed.
+func NewMemoryLocker(ttl time.Duration) (*MemoryLocker, error) {
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	return &MemoryLocker{ttl: ttl, leases: make(map[string]*memoryLock)}, nil
+}
+
+// TryAcquire acquires the lock if it is not held or its lease has expired.
+func (m *MemoryLocker) TryAcquire(_ context.Context, key string) (Lock, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	if l, ok := m.leases[key]; ok && time.Now().Before(l.expiresAt) {
+		return nil, false, nil
+	}
+	l := &memoryLock{locker: m, key: key, expiresAt: time.Now().Add(m.ttl)}
+	m.leases[key] = l
+	return l, true, nil
+}
+
+type memoryLock struct {
+	locker    *MemoryLocker
+	key       string
+	expiresAt time.Time
+}
+
+func (l *memoryLock) Renew(context.Context) error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	if l.locker.leases[l.key] != l || !time.Now().Before(l.expiresAt) {
+		return ErrLost
+	}
+	l.expiresAt = time.Now().Add(l.locker.ttl)
+	return nil
+}
+
+func (l *memoryLock) Release(context.Context) error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	if l.locker.leases[l.key] == l {
+		delete(l.locker.leases, l.key)
+	}
+	return nil
+}
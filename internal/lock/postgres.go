@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// PostgresLocker implements locks with Postgres session level advisory locks.
+// Every held lock pins a connection of the pool, since advisory locks belong to the session;
+// the lock is released by Postgres when the session ends. A connection whose lock could not be
+// verified or released is discarded instead of being returned to the pool, so that the session ends.
+type PostgresLocker struct {
+	db *sql.DB
+}
+
+// NewPostgresLocker creates a new advisory locker.
+func NewPostgresLocker(db *sql.DB) (*PostgresLocker, error) {
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+	return &PostgresLocker{db: db}, nil
+}
+
+// TryAcquire tries to acquire the advisory lock of the key without blocking.
+func (p *PostgresLocker) TryAcquire(ctx context.Context, key string) (Lock, bool, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to get connection")
+	}
+
+	id := advisoryKey(key)
+	var ok bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&ok)
+	if err != nil {
+		discard(conn)
+		return nil, false, errors.Wrap(err, "failed to try advisory lock")
+	}
+	if !ok {
+		return nil, false, conn.Close()
+	}
+	return &postgresLock{conn: conn, id: id}, true, nil
+}
+
+type postgresLock struct {
+	conn *sql.Conn
+	id   int64
+}
+
+// Renew verifies that the session holding the lock is still alive.
+func (l *postgresLock) Renew(ctx context.Context) error {
+	var one int
+	if err := l.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		discard(l.conn)
+		return ErrLost
+	}
+	return nil
+}
+
+func (l *postgresLock) Release(ctx context.Context) error {
+	var ok bool
+	err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.id).Scan(&ok)
+	if err != nil {
+		discard(l.conn)
+		return errors.Wrap(err, "failed to release advisory lock")
+	}
+	_ = l.conn.Close()
+	if !ok {
+		return ErrLost
+	}
+	return nil
+}
+
+// discard closes the connection without returning it to the pool, ending the session and
+// with it any advisory lock it may still hold.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
+// advisoryKey maps a lock key to the 64 bit key space of advisory locks.
+func advisoryKey(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,184 @@
+// Package logging sets up the patron logger with a level that can be changed at runtime.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronzerolog "github.com/beatlabs/patron/log/zerolog"
+	"github.com/rs/zerolog"
+)
+
+// sourceSkip is the number of frames between the caller of the patron log functions and the source hook.
+const sourceSkip = 6
+
+var (
+	levels = map[log.Level]int32{
+		log.DebugLevel: 0,
+		log.InfoLevel:  1,
+		log.WarnLevel:  2,
+		log.ErrorLevel: 3,
+		log.FatalLevel: 4,
+		log.PanicLevel: 5,
+	}
+	level = levels[log.InfoLevel]
+)
+
+// Setup replaces the patron logger with one whose level can be changed with SetLevel.
+// It has to be called after patron.Setup, since patron sets up its logger only once.
+func Setup(name, version string, lvl log.Level) error {
+	err := SetLevel(lvl)
+	if err != nil {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "failed to get hostname")
+	}
+
+	zerolog.LevelFieldName = "lvl"
+	zerolog.MessageFieldName = "msg"
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	zl := zerolog.New(os.Stdout).With().Timestamp().Logger().Hook(sourceHook{})
+
+	f := func(ff map[string]interface{}) log.Logger {
+		return &logger{Logger: patronzerolog.NewLogger(&zl, log.DebugLevel, ff)}
+	}
+	return log.Setup(f, map[string]interface{}{
+		"srv":  name,
+		"ver":  version,
+		"host": hostname,
+	})
+}
+
+// SetLevel changes the level of the logger.
+func SetLevel(lvl log.Level) error {
+	v, ok := levels[lvl]
+	if !ok {
+		return errors.Errorf("invalid log level %q", lvl)
+	}
+	atomic.StoreInt32(&level, v)
+	return nil
+}
+
+// Level returns the current level of the logger.
+func Level() log.Level {
+	v := atomic.LoadInt32(&level)
+	for l, lv := range levels {
+		if lv == v {
+			return l
+		}
+	}
+	return log.InfoLevel
+}
+
+type debugKey struct{}
+
+// WithDebug returns a context for which FromContext returns a logger logging at debug level,
+// regardless of the current level.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// FromContext returns the logger of the context, logging at debug level if the context was created with WithDebug.
+func FromContext(ctx context.Context) log.Logger {
+	l := log.FromContext(ctx)
+	if debug, _ := ctx.Value(debugKey{}).(bool); !debug {
+		return l
+	}
+	if ll, ok := l.(*logger); ok {
+		return &logger{Logger: ll.Logger, debug: true}
+	}
+	if ll, ok := log.Sub(nil).(*logger); ok {
+		return &logger{Logger: ll.Logger, debug: true}
+	}
+	return l
+}
+
+// logger filters the entries of a debug level patron logger by the current level.
+type logger struct {
+	log.Logger
+	debug bool
+}
+
+func (l *logger) enabled(lvl log.Level) bool {
+	return l.debug || levels[lvl] >= atomic.LoadInt32(&level)
+}
+
+func (l *logger) Sub(ff map[string]interface{}) log.Logger {
+	return &logger{Logger: l.Logger.Sub(ff), debug: l.debug}
+}
+
+func (l *logger) Error(args ...interface{}) {
+	if l.enabled(log.ErrorLevel) {
+		l.Logger.Error(args...)
+	}
+}
+
+func (l *logger) Errorf(msg string, args ...interface{}) {
+	if l.enabled(log.ErrorLevel) {
+		l.Logger.Errorf(msg, args...)
+	}
+}
+
+func (l *logger) Warn(args ...interface{}) {
+	if l.enabled(log.WarnLevel) {
+		l.Logger.Warn(args...)
+	}
+}
+
+func (l *logger) Warnf(msg string, args ...interface{}) {
+	if l.enabled(log.WarnLevel) {
+		l.Logger.Warnf(msg, args...)
+	}
+}
+
+func (l *logger) Info(args ...interface{}) {
+	if l.enabled(log.InfoLevel) {
+		l.Logger.Info(args...)
+	}
+}
+
+func (l *logger) Infof(msg string, args ...interface{}) {
+	if l.enabled(log.InfoLevel) {
+		l.Logger.Infof(msg, args...)
+	}
+}
+
+func (l *logger) Debug(args ...interface{}) {
+	if l.enabled(log.DebugLevel) {
+		l.Logger.Debug(args...)
+	}
+}
+
+func (l *logger) Debugf(msg string, args ...interface{}) {
+	if l.enabled(log.DebugLevel) {
+		l.Logger.Debugf(msg, args...)
+	}
+}
+
+// sourceHook adds the source file and line of the log call, like the patron zerolog hook.
+type sourceHook struct{}
+
+func (sourceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	_, file, line, ok := runtime.Caller(sourceSkip)
+	if !ok || file == "" {
+		return
+	}
+	d, f := filepath.Split(file)
+	d = path.Base(d)
+	if d == "." || d == "" {
+		e.Str("src", fmt.Sprintf("%s:%d", f, line))
+		return
+	}
+	e.Str("src", fmt.Sprintf("%s/%s:%d", d, f, line))
+}
@@ -0,0 +1,97 @@
+// Package mention extracts the @username mentions of article bodies and comments.
+package mention
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// MaxMentions is the maximum number of users mentioned in a text that are notified,
+// to keep a single comment from notifying everyone.
+const MaxMentions = 20
+
+var (
+	// a mention is an @ not preceded by a word character, which excludes email addresses.
+	pattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]{0,39})`)
+	fenced  = regexp.MustCompile("(?s)```.*?```")
+	inline  = regexp.MustCompile("`[^`\n]*`")
+)
+
+// Parse returns the unique usernames mentioned in the markdown text, in order of appearance.
+// Mentions in code blocks and code spans are ignored.
+func Parse(text string) []string {
+	text = fenced.ReplaceAllString(text, "")
+	text = inline.ReplaceAllString(text, "")
+
+	var uu []string
+	seen := make(map[string]bool)
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		u := strings.TrimRight(m[1], ".-")
+		k := strings.ToLower(u)
+		if u == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		uu = append(uu, u)
+	}
+	return uu
+}
+
+// ExistsFunc returns the IDs of the users with the usernames, which are matched ignoring case.
+// Unknown usernames are omitted.
+type ExistsFunc func(ctx context.Context, usernames []string) (map[string]string, error)
+
+// Mention of a user in a text.
+type Mention struct {
+	Username string
+	UserID   string
+}
+
+// Resolve parses the text and returns the mentions of existing users, up to MaxMentions,
+// excluding the author of the text.
+func Resolve(ctx context.Context, text, authorID string, exists ExistsFunc) ([]Mention, error) {
+	uu := Parse(text)
+	if len(uu) == 0 {
+		return nil, nil
+	}
+	ids, err := exists(ctx, uu)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up mentioned users")
+	}
+	// the usernames are returned as stored, which may differ in case from the mentions.
+	byName := make(map[string]string, len(ids))
+	for u, id := range ids {
+		byName[strings.ToLower(u)] = id
+	}
+	var mm []Mention
+	for _, u := range uu {
+		id, ok := byName[strings.ToLower(u)]
+		if !ok || id == authorID {
+			continue
+		}
+		mm = append(mm, Mention{Username: u, UserID: id})
+		if len(mm) == MaxMentions {
+			break
+		}
+	}
+	return mm, nil
+}
+
+// Diff returns the mentions of the updated text that were not in the previous version,
+// so that users are notified once when a text is edited.
+func Diff(previous, current []Mention) []Mention {
+	old := make(map[string]bool, len(previous))
+	for _, m := range previous {
+		old[m.UserID] = true
+	}
+	var mm []Mention
+	for _, m := range current {
+		if !old[m.UserID] {
+			mm = append(mm, m)
+		}
+	}
+	return mm
+}
@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tracing"
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+)
+
+// UserIDFunc returns the ID of the user of the request as received, or an empty string.
+type UserIDFunc func(r *http.Request) string
+
+// AccessLogOptionFunc defines an option func for the access log.
+type AccessLogOptionFunc func(*AccessLog) error
+
+// SampleRate option for setting the fraction [0, 1] of successful requests that are logged.
+// Requests with a 4xx or 5xx status are always logged.
+func SampleRate(rate float64) AccessLogOptionFunc {
+	return func(a *AccessLog) error {
+		if rate < 0 || rate > 1 {
+			return errors.New("sample rate must be in [0, 1]")
+		}
+		a.sampleRate = rate
+		return nil
+	}
+}
+
+// UserID option for setting the func extracting the user ID of the request.
+func UserID(f UserIDFunc) AccessLogOptionFunc {
+	return func(a *AccessLog) error {
+		if f == nil {
+			return errors.New("user id func is nil")
+		}
+		a.userID = f
+		return nil
+	}
+}
+
+// AccessLogRoutes option for setting the routes whose path templates are logged. Requests not matching
+// any of them are logged without a route.
+func AccessLogRoutes(rr []patronhttp.Route) AccessLogOptionFunc {
+	return func(a *AccessLog) error {
+		if len(rr) == 0 {
+			return errors.New("routes are empty")
+		}
+		for _, r := range rr {
+			a.routes = append(a.routes, routeTemplate{method: r.Method, pattern: r.Pattern, segments: strings.Split(r.Pattern, "/")})
+		}
+		return nil
+	}
+}
+
+// AccessLog writes one JSON line per request, suitable for ingestion into log pipelines.
+type AccessLog struct {
+	mu         sync.Mutex
+	w          io.Writer
+	enc        *json.Encoder
+	sampleRate float64
+	userID     UserIDFunc
+	rnd        *rand.Rand
+	routes     []routeTemplate
+}
+
+type accessEntry struct {
+	Time      string  `json:"time"`
+	Method    string  `json:"method"`
+	Route     string  `json:"route,omitempty"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Bytes     int     `json:"bytes"`
+	UserID    string  `json:"user_id,omitempty"`
+	TraceID   string  `json:"trace_id,omitempty"`
+}
+
+// NewAccessLog creates a new access log writing to w. By default every request is logged.
+func NewAccessLog(w io.Writer, oo ...AccessLogOptionFunc) (*AccessLog, error) {
+	if w == nil {
+		return nil, errors.New("writer is nil")
+	}
+	a := AccessLog{
+		w:          w,
+		enc:        json.NewEncoder(w),
+		sampleRate: 1,
+		userID:     func(*http.Request) string { return "" },
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, o := range oo {
+		err := o(&a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &a, nil
+}
+
+// NewAccessLogFromEnv creates an access log writing to stdout if REALWORLD_ACCESS_LOG is true, logging the
+// fraction REALWORLD_ACCESS_LOG_SAMPLE_RATE of the successful requests (default 1).
+// It returns false if the access log is not enabled.
+func NewAccessLogFromEnv(oo ...AccessLogOptionFunc) (*AccessLog, bool, error) {
+	if os.Getenv("REALWORLD_ACCESS_LOG") != "true" {
+		return nil, false, nil
+	}
+	if v, ok := os.LookupEnv("REALWORLD_ACCESS_LOG_SAMPLE_RATE"); ok {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "env var for access log sample rate is not valid")
+		}
+		oo = append(oo, SampleRate(rate))
+	}
+	a, err := NewAccessLog(os.Stdout, oo...)
+	if err != nil {
+		return nil, false, err
+	}
+	return a, true, nil
+}
+
+// Middleware returns a MiddlewareFunc logging the requests. It has to be a global middleware, ahead of the
+// router, so that the requests rejected by authentication or not routed at all are logged too.
+// The route is the matching template of the routes of the access log, since the router does not expose it,
+// and the trace ID is the one propagated by the caller, since the span of the request starts at the route.
+func (a *AccessLog) Middleware() patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := newStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			status := sw.Status()
+			if status < http.StatusBadRequest && !a.sampled() {
+				return
+			}
+
+			a.write(accessEntry{
+				Time:      start.UTC().Format(time.RFC3339Nano),
+				Method:    r.Method,
+				Route:     a.route(r),
+				Path:      r.URL.Path,
+				Status:    status,
+				LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+				Bytes:     sw.bytes,
+				UserID:    a.userID(r),
+				TraceID:   traceID(r),
+			})
+		})
+	}
+}
+
+// routeTemplate is a path template of the router, split into segments, where :name matches a segment
+// and *name the rest of the path.
+type routeTemplate struct {
+	method   string
+	pattern  string
+	segments []string
+}
+
+func (t routeTemplate) match(method string, ss []string) bool {
+	if t.method != method {
+		return false
+	}
+	for i, s := range t.segments {
+		if strings.HasPrefix(s, "*") {
+			return true
+		}
+		if i >= len(ss) || (!strings.HasPrefix(s, ":") && s != ss[i]) || (strings.HasPrefix(s, ":") && ss[i] == "") {
+			return false
+		}
+	}
+	return len(ss) == len(t.segments)
+}
+
+// route returns the template of the request. The router rejects conflicting templates, so at most one matches.
+func (a *AccessLog) route(r *http.Request) string {
+	ss := strings.Split(r.URL.Path, "/")
+	for _, t := range a.routes {
+		if t.match(r.Method, ss) {
+			return t.pattern
+		}
+	}
+	return ""
+}
+
+// traceID returns the ID of the trace of the context, or the one propagated by the caller in the headers.
+func traceID(r *http.Request) string {
+	if id := tracing.TraceID(r.Context()); id != "" {
+		return id
+	}
+	sc, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+	if err != nil {
+		return ""
+	}
+	if jc, ok := sc.(jaeger.SpanContext); ok {
+		return jc.TraceID().String()
+	}
+	return ""
+}
+
+func (a *AccessLog) sampled() bool {
+	if a.sampleRate >= 1 {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rnd.Float64() < a.sampleRate
+}
+
+func (a *AccessLog) write(e accessEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(e); err != nil {
+		log.Errorf("failed to write access log: %v", err)
+	}
+}
+
+// statusWriter records the status code and the number of bytes written.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w}
+}
+
+// Status returns the status code of the response.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Write writes the data and counts the bytes.
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// WriteHeader writes and records the status code.
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+const (
+	// DefaultBodyLimit is the maximum request body size of API routes.
+	DefaultBodyLimit = 64 << 10
+	// LoginBodyLimit is the maximum request body size of the login and registration routes.
+	LoginBodyLimit = 4 << 10
+	// ArticleBodyLimit is the maximum request body size of the article create and update routes.
+	ArticleBodyLimit = 1 << 20
+)
+
+// NewBodyLimit creates a MiddlewareFunc that limits the request body to n bytes.
+// Requests declaring a larger Content-Length are rejected with a 413 before the handler runs,
+// the rest fail when the handler reads past the limit, see payload.Decode.
+func NewBodyLimit(n int64) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				apierror.Write(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
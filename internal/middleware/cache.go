@@ -0,0 +1,315 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/signing"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tenant"
+)
+
+const cacheHeader = "X-Cache"
+
+// ResponseCache caches the responses of anonymous GET requests, keyed by tenant, path, query and the
+// request headers listed in the Vary header of the response. Responses that are private, not to be stored,
+// setting cookies or varying by every header are not cached.
+// Fresh responses are served from the cache for the TTL. Afterwards, and for the stale-while-revalidate
+// period, the stale response is still served while it is refreshed in the background.
+// Authenticated and signed requests always bypass the cache.
+type ResponseCache struct {
+	sync.Mutex
+	ttl        time.Duration
+	stale      time.Duration
+	maxEntries int
+	entries    map[string]*cacheEntry
+	refreshing map[string]bool
+	// vary has the request headers the responses of a path and query vary by.
+	vary map[string][]string
+}
+
+type cacheEntry struct {
+	base     string
+	code     int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+}
+
+// NewResponseCache creates a new response cache.
+func NewResponseCache(ttl, staleWhileRevalidate time.Duration, maxEntries int) (*ResponseCache, error) {
+	if maxEntries <= 0 {
+		return nil, errors.New("max entries must be positive")
+	}
+	c := &ResponseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*cacheEntry),
+		refreshing: make(map[string]bool),
+		vary:       make(map[string][]string),
+	}
+	err := c.SetTTL(ttl, staleWhileRevalidate)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetTTL changes the TTL and the stale-while-revalidate period at runtime.
+func (c *ResponseCache) SetTTL(ttl, staleWhileRevalidate time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("ttl must be positive")
+	}
+	if staleWhileRevalidate < 0 {
+		return errors.New("stale while revalidate must not be negative")
+	}
+	c.Lock()
+	c.ttl = ttl
+	c.stale = staleWhileRevalidate
+	c.Unlock()
+	return nil
+}
+
+// Purge removes all cached responses.
+func (c *ResponseCache) Purge() {
+	c.Lock()
+	c.entries = make(map[string]*cacheEntry)
+	c.vary = make(map[string][]string)
+	c.Unlock()
+}
+
+// Middleware returns a MiddlewareFunc that serves responses from the cache.
+func (c *ResponseCache) Middleware() patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.Header.Get("Authorization") != "" || r.Header.Get(signing.Header) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			base := tenant.ID(r.Context()) + ":" + r.URL.Path + "?" + r.URL.Query().Encode()
+			// the outer middlewares may have set the Vary header already, e.g. the i18n one.
+			outer := append([]string(nil), w.Header()["Vary"]...)
+			e, fresh, stale := c.lookup(base, r)
+			switch {
+			case fresh:
+				e.write(w, "HIT")
+				return
+			case stale:
+				e.write(w, "STALE")
+				c.revalidate(base, outer, next, r)
+				return
+			}
+
+			rec := newRecorder()
+			next.ServeHTTP(rec, r)
+			c.store(base, outer, r, rec)
+			rec.entry(base).write(w, "MISS")
+		})
+	}
+}
+
+func (c *ResponseCache) lookup(base string, r *http.Request) (*cacheEntry, bool, bool) {
+	c.Lock()
+	defer c.Unlock()
+	key := variantKey(base, c.vary[base], r)
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	age := time.Since(e.storedAt)
+	if age < c.ttl {
+		return e, true, false
+	}
+	if age < c.ttl+c.stale {
+		return e, false, true
+	}
+	c.delete(key)
+	return nil, false, false
+}
+
+func (c *ResponseCache) revalidate(base string, outer []string, next http.Handler, r *http.Request) {
+	c.Lock()
+	key := variantKey(base, c.vary[base], r)
+	if c.refreshing[key] {
+		c.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.Unlock()
+
+	// the request context is cancelled once the stale response is written, but its values, e.g. the tenant
+	// the entry is keyed by, have to be kept.
+	req := r.WithContext(context.WithoutCancel(r.Context()))
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Errorf("recovering from a panic while revalidating %s: %v", key, p)
+			}
+			c.Lock()
+			delete(c.refreshing, key)
+			c.Unlock()
+		}()
+		rec := newRecorder()
+		next.ServeHTTP(rec, req)
+		c.store(base, outer, req, rec)
+	}()
+}
+
+func (c *ResponseCache) store(base string, outer []string, r *http.Request, rec *recorder) {
+	if rec.code != http.StatusOK || !cacheable(rec.header) {
+		return
+	}
+	names, ok := varyHeaders(append(outer, rec.header["Vary"]...))
+	if !ok {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	key := variantKey(base, names, r)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.vary[base] = names
+	c.entries[key] = rec.entry(base)
+}
+
+// delete removes the entry of the key. It has to be called with the lock held.
+func (c *ResponseCache) delete(key string) {
+	if e, ok := c.entries[key]; ok {
+		// the other variants of the path can't be looked up without the headers they vary by, until stored again.
+		delete(c.vary, e.base)
+		delete(c.entries, key)
+	}
+}
+
+// cacheable returns false for the responses that must not be shared between clients.
+func cacheable(h http.Header) bool {
+	if len(h["Set-Cookie"]) > 0 {
+		return false
+	}
+	for _, v := range h["Cache-Control"] {
+		for _, d := range strings.Split(v, ",") {
+			switch strings.ToLower(strings.TrimSpace(d)) {
+			case "private", "no-store":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// varyHeaders returns the sorted canonical names of the Vary header values, or false if the response varies by every header.
+func varyHeaders(vv []string) ([]string, bool) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, v := range vv {
+		for _, n := range strings.Split(v, ",") {
+			n = http.CanonicalHeaderKey(strings.TrimSpace(n))
+			switch {
+			case n == "*":
+				return nil, false
+			case n == "" || seen[n]:
+				continue
+			}
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// variantKey returns the key of the response to the request, from the base key and the values of the headers it varies by.
+func variantKey(base string, names []string, r *http.Request) string {
+	if len(names) == 0 {
+		return base
+	}
+	var b strings.Builder
+	b.WriteString(base)
+	for _, n := range names {
+		b.WriteString("\x00")
+		b.WriteString(n)
+		b.WriteString("=")
+		b.WriteString(strings.Join(r.Header[n], ","))
+	}
+	return b.String()
+}
+
+// evict removes the expired entries or, if there are none, an arbitrary entry. It has to be called with the lock held.
+func (c *ResponseCache) evict() {
+	n := len(c.entries)
+	for k, e := range c.entries {
+		if time.Since(e.storedAt) >= c.ttl+c.stale {
+			c.delete(k)
+		}
+	}
+	if len(c.entries) < n {
+		return
+	}
+	for k := range c.entries {
+		c.delete(k)
+		return
+	}
+}
+
+func (e *cacheEntry) write(w http.ResponseWriter, status string) {
+	h := w.Header()
+	for k, vv := range e.header {
+		h[k] = vv
+	}
+	h.Set(cacheHeader, status)
+	w.WriteHeader(e.code)
+	if _, err := w.Write(e.body); err != nil {
+		log.Errorf("failed to write response: %v", err)
+	}
+}
+
+// recorder captures a response in memory.
+type recorder struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+// Header returns the recorded header.
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// Write records the data.
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+// WriteHeader records the status code.
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *recorder) entry(base string) *cacheEntry {
+	code := r.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	h := make(http.Header, len(r.header))
+	for k, vv := range r.header {
+		h[k] = append([]string(nil), vv...)
+	}
+	return &cacheEntry{base: base, code: code, header: h, body: r.body.Bytes(), storedAt: time.Now()}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/logging"
+)
+
+// DebugHeader is the header carrying the admin token to enable debug logging for a single request.
+const DebugHeader = "X-Debug"
+
+// TokenValidator interface for validating admin tokens.
+type TokenValidator interface {
+	Valid(token string) bool
+}
+
+// NewDebug creates a MiddlewareFunc that elevates logging to debug level for requests
+// carrying a valid admin token in the X-Debug header. Handlers have to use logging.FromContext
+// to get the elevated logger. Requests with an invalid token are served normally.
+func NewDebug(v TokenValidator) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(DebugHeader)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !v.Valid(token) {
+				log.Warnf("invalid debug token for %s %s", r.Method, r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := logging.WithDebug(r.Context())
+			logging.FromContext(ctx).Debugf("debug logging enabled for %s %s", r.Method, r.URL.Path)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const maxFaultLatency = 30 * time.Second
+
+var faultCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "http",
+	Name:      "injected_faults_total",
+	Help:      "Number of faults injected into requests, by kind.",
+}, []string{"kind"})
+
+func init() {
+	prometheus.MustRegister(faultCounter)
+}
+
+// FaultRule defines the faults injected into a percentage of the requests whose path starts with the prefix.
+// A matched request is delayed by the latency, and then either dropped, failed with the status, or served.
+type FaultRule struct {
+	PathPrefix string          `json:"pathPrefix"`
+	Percent    float64         `json:"percent"`
+	Latency    config.Duration `json:"latency,omitempty"`
+	Status     int             `json:"status,omitempty"`
+	Drop       bool            `json:"drop,omitempty"`
+}
+
+// Validate checks the rule values.
+func (r FaultRule) Validate() error {
+	if r.Percent <= 0 || r.Percent > 100 {
+		return errors.New("percent must be in (0, 100]")
+	}
+	if r.Latency < 0 || time.Duration(r.Latency) > maxFaultLatency {
+		return errors.Errorf("latency must be in [0, %s]", maxFaultLatency)
+	}
+	if r.Status != 0 && (r.Status < 400 || r.Status > 599) {
+		return errors.New("status must be a 4xx or 5xx code")
+	}
+	if r.Drop && r.Status != 0 {
+		return errors.New("a rule can't both drop and fail requests")
+	}
+	if r.Latency == 0 && r.Status == 0 && !r.Drop {
+		return errors.New("a rule must inject latency, a status or drop requests")
+	}
+	return nil
+}
+
+// FaultInjector injects latency, errors and dropped connections into requests, for validating the retry
+// behaviour of clients in staging. It has no rules, and injects nothing, until they are set by the admin API.
+// The management and admin routes are never affected, so that the injection can always be turned off.
+type FaultInjector struct {
+	sync.RWMutex
+	rules []FaultRule
+	rnd   *rand.Rand
+}
+
+// NewFaultInjector creates a new fault injector without rules.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Rules returns the current rules.
+func (f *FaultInjector) Rules() []FaultRule {
+	f.RLock()
+	defer f.RUnlock()
+	rr := make([]FaultRule, len(f.rules))
+	copy(rr, f.rules)
+	return rr
+}
+
+// SetRules replaces the rules. The first rule matching a request applies, and no rules disable the injection.
+func (f *FaultInjector) SetRules(rr []FaultRule) error {
+	for i, r := range rr {
+		if err := r.Validate(); err != nil {
+			return errors.Wrapf(err, "rule %d is not valid", i)
+		}
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.rules = append([]FaultRule(nil), rr...)
+	if len(rr) > 0 {
+		log.Warnf("fault injection enabled with %d rules", len(rr))
+	} else {
+		log.Info("fault injection disabled")
+	}
+	return nil
+}
+
+// match returns the rule applying to the request path, if the request is selected by its percentage.
+func (f *FaultInjector) match(path string) (FaultRule, bool) {
+	f.Lock()
+	defer f.Unlock()
+	for _, r := range f.rules {
+		if strings.HasPrefix(path, r.PathPrefix) {
+			return r, f.rnd.Float64()*100 < r.Percent
+		}
+	}
+	return FaultRule{}, false
+}
+
+// Middleware returns a MiddlewareFunc injecting the faults of the matching rules.
+func (f *FaultInjector) Middleware() patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isManagement(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/admin/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rule, ok := f.match(r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if rule.Latency > 0 {
+				faultCounter.WithLabelValues("latency").Inc()
+				select {
+				case <-time.After(time.Duration(rule.Latency)):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			switch {
+			case rule.Drop:
+				faultCounter.WithLabelValues("drop").Inc()
+				drop(w)
+			case rule.Status != 0:
+				faultCounter.WithLabelValues("status").Inc()
+				apierror.Write(w, rule.Status, "fault injected")
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// drop closes the connection without a response. If the connection can't be hijacked, as with HTTP/2,
+// the handler is aborted, which resets the stream.
+func drop(w http.ResponseWriter) {
+	if hj, ok := w.(http.Hijacker); ok {
+		conn, _, err := hj.Hijack()
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
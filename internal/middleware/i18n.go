@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/i18n"
+)
+
+// NewI18n creates a MiddlewareFunc that translates the messages of RealWorld error responses
+// to the language negotiated from the Accept-Language header. Successful responses are passed through.
+func NewI18n(c *i18n.Catalog) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+			lang := c.Negotiate(r.Header.Get("Accept-Language"))
+			if lang == i18n.DefaultLanguage {
+				next.ServeHTTP(w, r)
+				return
+			}
+			tw := &translateWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r)
+			if tw.code != 0 {
+				tw.flush(c, lang)
+			}
+		})
+	}
+}
+
+// translateWriter passes successful responses through and buffers error responses for translation.
+type translateWriter struct {
+	http.ResponseWriter
+	code    int
+	written bool
+	buf     bytes.Buffer
+}
+
+// WriteHeader buffers error responses and writes the others.
+func (w *translateWriter) WriteHeader(code int) {
+	if w.code != 0 || w.written {
+		return
+	}
+	if code < http.StatusBadRequest {
+		w.written = true
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.code = code
+}
+
+// Write buffers the data of error responses and writes the others.
+func (w *translateWriter) Write(p []byte) (int, error) {
+	if w.code != 0 {
+		return w.buf.Write(p)
+	}
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *translateWriter) flush(c *i18n.Catalog, lang string) {
+	body := w.buf.Bytes()
+	var b apierror.Body
+	if err := json.Unmarshal(body, &b); err == nil && len(b.Errors.Body) > 0 {
+		for i, m := range b.Errors.Body {
+			b.Errors.Body[i] = c.Translate(lang, m)
+		}
+		if p, err := json.Marshal(b); err == nil {
+			body = p
+			w.Header().Set("Content-Language", lang)
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		log.Errorf("failed to write translated response: %v", err)
+	}
+}
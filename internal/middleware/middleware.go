@@ -0,0 +1,2 @@
+// Package middleware contains the HTTP middlewares applied to the RealWorld API routes.
+package middleware
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+const (
+	// DefaultCSP is the content security policy of the API responses, which are never rendered as documents.
+	DefaultCSP = "default-src 'none'; frame-ancestors 'none'"
+	// DocsCSP is a content security policy for the API docs UI, which loads its scripts and styles from the service.
+	DocsCSP = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'"
+	// DefaultHSTSMaxAge is the max age of the Strict-Transport-Security header.
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
+)
+
+// SecurityHeadersOptionFunc defines an option func for the security headers middleware.
+type SecurityHeadersOptionFunc func(*securityHeaders) error
+
+// CSP option for setting the Content-Security-Policy header. An empty policy omits the header.
+func CSP(policy string) SecurityHeadersOptionFunc {
+	return func(s *securityHeaders) error {
+		s.csp = policy
+		return nil
+	}
+}
+
+// HSTS option for setting the max age of the Strict-Transport-Security header. A zero max age omits the header.
+func HSTS(maxAge time.Duration) SecurityHeadersOptionFunc {
+	return func(s *securityHeaders) error {
+		if maxAge < 0 {
+			return errors.New("hsts max age must not be negative")
+		}
+		s.hstsMaxAge = maxAge
+		return nil
+	}
+}
+
+type securityHeaders struct {
+	csp        string
+	hstsMaxAge time.Duration
+}
+
+// NewSecurityHeaders creates a MiddlewareFunc that sets the security headers of the responses.
+// The Strict-Transport-Security header is only sent over HTTPS, as browsers ignore it otherwise.
+func NewSecurityHeaders(oo ...SecurityHeadersOptionFunc) (patronhttp.MiddlewareFunc, error) {
+	s := securityHeaders{csp: DefaultCSP, hstsMaxAge: DefaultHSTSMaxAge}
+	for _, o := range oo {
+		err := o(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", int64(s.hstsMaxAge/time.Second))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			if s.csp != "" {
+				h.Set("Content-Security-Policy", s.csp)
+			}
+			if s.hstsMaxAge > 0 && isHTTPS(r) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// managementPrefixes are the paths of the patron management routes and the status route, which are probed over plain HTTP.
+var managementPrefixes = []string{"/health", "/info", "/status", "/metrics", "/debug/"}
+
+// NewHTTPSRedirect creates a MiddlewareFunc that permanently redirects plain HTTP requests to HTTPS.
+// Requests forwarded by a TLS terminating proxy are detected by the X-Forwarded-Proto header.
+// The patron management routes are not redirected.
+func NewHTTPSRedirect() patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isHTTPS(r) || isManagement(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			u := *r.URL
+			u.Scheme = "https"
+			u.Host = r.Host
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				// 308 keeps the method and body of the request.
+				code = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, u.String(), code)
+		})
+	}
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func isManagement(path string) bool {
+	for _, p := range managementPrefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
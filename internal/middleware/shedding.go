@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"container/list"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultQueueTimeout = 100 * time.Millisecond
+	defaultCPUInterval  = time.Second
+)
+
+var (
+	inFlightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "realworld",
+		Subsystem: "http",
+		Name:      "in_flight_requests",
+		Help:      "Number of requests admitted by the load shedder and currently in flight.",
+	})
+	shedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "realworld",
+		Subsystem: "http",
+		Name:      "shed_requests_total",
+		Help:      "Number of requests rejected by the load shedder, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(inFlightGauge, shedCounter)
+}
+
+// ShedderOptionFunc defines an option func for the load shedder.
+type ShedderOptionFunc func(*Shedder) error
+
+// QueueTimeout option for setting how long a queued request waits for a free slot.
+func QueueTimeout(d time.Duration) ShedderOptionFunc {
+	return func(s *Shedder) error {
+		if d <= 0 {
+			return errors.New("queue timeout must be positive")
+		}
+		s.queueTimeout = d
+		return nil
+	}
+}
+
+// CPUThreshold option for enabling CPU based shedding, which is supported only on unix.
+// The threshold is the process CPU utilization (0-1] over all cores, above which new requests are rejected.
+func CPUThreshold(threshold float64) ShedderOptionFunc {
+	return func(s *Shedder) error {
+		if !cpuTimeSupported {
+			return errors.New("cpu based shedding is not supported on this platform")
+		}
+		if threshold <= 0 || threshold > 1 {
+			return errors.New("cpu threshold must be in (0, 1]")
+		}
+		s.cpuThreshold = threshold
+		return nil
+	}
+}
+
+// Shedder implements admission control by limiting the number of requests in flight
+// and the number of requests waiting for a slot. Requests that cannot be admitted
+// are rejected early with a 503 instead of queueing up and degrading tail latency.
+type Shedder struct {
+	sync.Mutex
+	maxInFlight  int
+	maxQueue     int
+	inFlight     int
+	waiters      *list.List
+	queueTimeout time.Duration
+	cpuThreshold float64
+	cpu          cpuSampler
+}
+
+// NewShedder creates a new load shedder.
+func NewShedder(maxInFlight, maxQueue int, oo ...ShedderOptionFunc) (*Shedder, error) {
+	if maxInFlight <= 0 {
+		return nil, errors.New("max in flight must be positive")
+	}
+	if maxQueue < 0 {
+		return nil, errors.New("max queue must not be negative")
+	}
+
+	s := Shedder{
+		maxInFlight:  maxInFlight,
+		maxQueue:     maxQueue,
+		waiters:      list.New(),
+		queueTimeout: defaultQueueTimeout,
+		cpu:          cpuSampler{interval: defaultCPUInterval},
+	}
+
+	for _, o := range oo {
+		err := o(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// SetLimits changes the in flight and queue limits at runtime.
+func (s *Shedder) SetLimits(maxInFlight, maxQueue int) error {
+	if maxInFlight <= 0 {
+		return errors.New("max in flight must be positive")
+	}
+	if maxQueue < 0 {
+		return errors.New("max queue must not be negative")
+	}
+	s.Lock()
+	s.maxInFlight = maxInFlight
+	s.maxQueue = maxQueue
+	s.grant()
+	s.Unlock()
+	return nil
+}
+
+// Middleware returns a MiddlewareFunc that applies the admission control.
+func (s *Shedder) Middleware() patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.cpuThreshold > 0 && s.cpu.utilization() > s.cpuThreshold {
+				shedCounter.WithLabelValues("cpu").Inc()
+				apierror.Write(w, http.StatusServiceUnavailable, "service overloaded")
+				return
+			}
+
+			reason, ok := s.acquire(r)
+			if !ok {
+				shedCounter.WithLabelValues(reason).Inc()
+				apierror.Write(w, http.StatusServiceUnavailable, "service overloaded")
+				return
+			}
+			inFlightGauge.Inc()
+			defer func() {
+				inFlightGauge.Dec()
+				s.release()
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (s *Shedder) acquire(r *http.Request) (string, bool) {
+	s.Lock()
+	if s.inFlight < s.maxInFlight {
+		s.inFlight++
+		s.Unlock()
+		return "", true
+	}
+	if s.waiters.Len() >= s.maxQueue {
+		s.Unlock()
+		return "queue", false
+	}
+	ch := make(chan struct{})
+	el := s.waiters.PushBack(ch)
+	s.Unlock()
+
+	tm := time.NewTimer(s.queueTimeout)
+	defer tm.Stop()
+
+	select {
+	case <-ch:
+		return "", true
+	case <-tm.C:
+	case <-r.Context().Done():
+	}
+
+	s.Lock()
+	select {
+	case <-ch:
+		// the slot was granted while timing out, hand it over to the next waiter.
+		s.inFlight--
+		s.grant()
+	default:
+		s.waiters.Remove(el)
+	}
+	s.Unlock()
+	return "timeout", false
+}
+
+func (s *Shedder) release() {
+	s.Lock()
+	s.inFlight--
+	s.grant()
+	s.Unlock()
+}
+
+// grant hands free slots to queued requests in FIFO order. It has to be called with the lock held.
+func (s *Shedder) grant() {
+	for s.inFlight < s.maxInFlight && s.waiters.Len() > 0 {
+		el := s.waiters.Front()
+		s.waiters.Remove(el)
+		s.inFlight++
+		close(el.Value.(chan struct{}))
+	}
+}
+
+// cpuSampler computes the CPU utilization of the process, refreshing the value at most once per interval.
+type cpuSampler struct {
+	sync.Mutex
+	interval time.Duration
+	lastAt   time.Time
+	lastCPU  time.Duration
+	value    float64
+}
+
+func (c *cpuSampler) utilization() float64 {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(c.lastAt)
+	if elapsed < c.interval {
+		return c.value
+	}
+
+	cpu, err := processCPUTime()
+	if err != nil {
+		log.Errorf("failed to get resource usage: %v", err)
+		return c.value
+	}
+	if !c.lastAt.IsZero() {
+		c.value = float64(cpu-c.lastCPU) / float64(elapsed) / float64(runtime.NumCPU())
+	}
+	c.lastAt = now
+	c.lastCPU = cpu
+	return c.value
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package middleware
+
+import (
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const cpuTimeSupported = false
+
+func processCPUTime() (time.Duration, error) {
+	return 0, errors.New("process cpu time is not supported on this platform")
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package middleware
+
+import (
+	"syscall"
+	"time"
+)
+
+const cpuTimeSupported = true
+
+// processCPUTime returns the user and system CPU time of the process.
+func processCPUTime() (time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, err
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
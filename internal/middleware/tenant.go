@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/tenant"
+)
+
+// NewTenant creates a MiddlewareFunc that resolves the tenant of the request and adds it to the request context.
+// Requests of unknown tenants are rejected with a 404. The patron management routes and the admin API
+// operate on the whole service and are not tenant-scoped.
+func NewTenant(reg *tenant.Registry) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isManagement(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/admin/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			t, ok := reg.Resolve(r)
+			if !ok {
+				apierror.Write(w, http.StatusNotFound, "unknown tenant")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), t)))
+		})
+	}
+}
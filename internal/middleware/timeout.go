@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// DefaultTimeout is the request deadline applied to API routes.
+// It is kept below the write timeout of the patron HTTP component (10s) so that
+// the timeout error body can still be written before the connection is closed.
+const DefaultTimeout = 8 * time.Second
+
+// NewTimeout creates a MiddlewareFunc that attaches a deadline to the request context.
+// Handlers are expected to pass the request context down to the repositories so that
+// the deadline cancels any in-flight work. If the handler does not complete in time,
+// a 503 response with a RealWorld error body is returned instead.
+func NewTimeout(d time.Duration) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cnl := context.WithTimeout(r.Context(), d)
+			defer cnl()
+
+			tw := &timeoutWriter{w: w, h: make(http.Header)}
+			chDone := make(chan struct{})
+			chPanic := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						chPanic <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(chDone)
+			}()
+
+			select {
+			case p := <-chPanic:
+				// re-panic on the serving goroutine so that the recovery middleware handles it.
+				panic(p)
+			case <-chDone:
+				tw.flush()
+			case <-ctx.Done():
+				tw.timeout()
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response of the handler until it either completes or times out.
+type timeoutWriter struct {
+	sync.Mutex
+	w        http.ResponseWriter
+	h        http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+// Header returns the buffered header.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// Write buffers the data unless the request has already timed out.
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+// WriteHeader records the status code unless the request has already timed out.
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) flush() {
+	tw.Lock()
+	defer tw.Unlock()
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.code)
+	if _, err := tw.w.Write(tw.buf.Bytes()); err != nil {
+		log.Errorf("failed to write response: %v", err)
+	}
+}
+
+func (tw *timeoutWriter) timeout() {
+	tw.Lock()
+	defer tw.Unlock()
+	tw.timedOut = true
+	apierror.Write(tw.w, http.StatusServiceUnavailable, "request timed out")
+}
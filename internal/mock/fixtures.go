@@ -0,0 +1,66 @@
+package mock
+
+import "time"
+
+// Password is the password of every fixture user.
+const Password = "password"
+
+// epoch is the creation time of the first fixture. The clock of the store starts after the fixtures
+// and advances by a second on every write, so that the responses are reproducible.
+var epoch = time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+var fixtureUsers = []user{
+	{Username: "jake", Email: "jake@example.com", Bio: "I work at statefarm", Image: "https://api.realworld.io/images/smiley-cyrus.jpeg"},
+	{Username: "jane", Email: "jane@example.com", Bio: "Gopher and occasional writer", Image: "https://api.realworld.io/images/demo-avatar.png"},
+	{Username: "john", Email: "john@example.com"},
+}
+
+// fixtureFollows maps the followers to the users they follow.
+var fixtureFollows = map[string][]string{
+	"jake": {"jane"},
+	"jane": {"jake", "john"},
+}
+
+var fixtureArticles = []struct {
+	title, description, body, author string
+	tags, favoritedBy                []string
+}{
+	{
+		title:       "How to train your dragon",
+		description: "Ever wonder how?",
+		body:        "It takes a Jacobian.\n\nAnd a lot of patience.",
+		author:      "jake",
+		tags:        []string{"dragons", "training"},
+		favoritedBy: []string{"jane"},
+	},
+	{
+		title:       "Error handling in Go",
+		description: "Errors are values",
+		body:        "Wrap errors with context, and check them with `errors.Is`.",
+		author:      "jane",
+		tags:        []string{"go", "errors"},
+		favoritedBy: []string{"jake", "john"},
+	},
+	{
+		title:       "Context cancellation",
+		description: "Stop work nobody waits for",
+		body:        "Pass the request context down, and return when it is done.",
+		author:      "jane",
+		tags:        []string{"go"},
+	},
+	{
+		title:       "Welcome to Conduit",
+		description: "A place to share your knowledge",
+		body:        "Conduit is a social blogging site.",
+		author:      "john",
+		favoritedBy: []string{"jake"},
+	},
+}
+
+var fixtureComments = []struct {
+	article, author, body string
+}{
+	{article: "how-to-train-your-dragon", author: "jane", body: "Great advice!"},
+	{article: "how-to-train-your-dragon", author: "john", body: "What about the patience part?"},
+	{article: "error-handling-in-go", author: "jake", body: "Thanks, this cleared things up."},
+}
@@ -0,0 +1,497 @@
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/query"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/render"
+	"github.com/julienschmidt/httprouter"
+)
+
+// ResetPath is the path of the route restoring the fixtures, to be called between contract tests.
+const ResetPath = "/mock/reset"
+
+const maxBodyBytes = 1 << 20
+
+// feedSlug is served by the article route, since the router does not allow a static segment next to a parameter.
+const feedSlug = "feed"
+
+type userView struct {
+	Email    string `json:"email"`
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Bio      string `json:"bio"`
+	Image    string `json:"image"`
+}
+
+type profileView struct {
+	Username  string `json:"username"`
+	Bio       string `json:"bio"`
+	Image     string `json:"image"`
+	Following bool   `json:"following"`
+}
+
+type articleView struct {
+	Slug           string      `json:"slug"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description"`
+	Body           string      `json:"body"`
+	TagList        []string    `json:"tagList"`
+	CreatedAt      render.Time `json:"createdAt"`
+	UpdatedAt      render.Time `json:"updatedAt"`
+	Favorited      bool        `json:"favorited"`
+	FavoritesCount int         `json:"favoritesCount"`
+	Author         profileView `json:"author"`
+}
+
+type commentView struct {
+	ID        int         `json:"id"`
+	CreatedAt render.Time `json:"createdAt"`
+	UpdatedAt render.Time `json:"updatedAt"`
+	Body      string      `json:"body"`
+	Author    profileView `json:"author"`
+}
+
+type userRequest struct {
+	User struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+		Password *string `json:"password"`
+		Bio      *string `json:"bio"`
+		Image    *string `json:"image"`
+	} `json:"user"`
+}
+
+type articleRequest struct {
+	Article struct {
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Body        string   `json:"body"`
+		TagList     []string `json:"tagList"`
+	} `json:"article"`
+}
+
+type commentRequest struct {
+	Comment struct {
+		Body string `json:"body"`
+	} `json:"comment"`
+}
+
+// response of a handler, written as JSON unless the body is nil.
+type response struct {
+	code int
+	body interface{}
+}
+
+// handlerFunc handles a request of the user, which is nil for anonymous requests.
+// It is called with the lock of the store held.
+type handlerFunc func(r *http.Request, me *user) response
+
+type server struct {
+	store *Store
+	rnd   *render.Renderer
+}
+
+// Routes creates the routes of the RealWorld API served from the store, and the POST /mock/reset route.
+func Routes(s *Store) ([]patronhttp.Route, error) {
+	rnd, err := render.New()
+	if err != nil {
+		return nil, err
+	}
+	srv := server{store: s, rnd: rnd}
+
+	reset := func(w http.ResponseWriter, r *http.Request) {
+		s.Reset()
+		log.Info("mock fixtures restored")
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	return []patronhttp.Route{
+		patronhttp.NewRouteRaw("/api/users/login", http.MethodPost, srv.handle(false, srv.login), true),
+		patronhttp.NewRouteRaw("/api/users", http.MethodPost, srv.handle(false, srv.register), true),
+		patronhttp.NewRouteRaw("/api/user", http.MethodGet, srv.handle(true, srv.currentUser), true),
+		patronhttp.NewRouteRaw("/api/user", http.MethodPut, srv.handle(true, srv.updateUser), true),
+		patronhttp.NewRouteRaw("/api/profiles/:username", http.MethodGet, srv.handle(false, srv.profile), true),
+		patronhttp.NewRouteRaw("/api/profiles/:username/follow", http.MethodPost, srv.handle(true, srv.follow), true),
+		patronhttp.NewRouteRaw("/api/profiles/:username/follow", http.MethodDelete, srv.handle(true, srv.unfollow), true),
+		patronhttp.NewRouteRaw("/api/articles", http.MethodGet, srv.handle(false, srv.listArticles), true),
+		patronhttp.NewRouteRaw("/api/articles", http.MethodPost, srv.handle(true, srv.createArticle), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug", http.MethodGet, srv.handle(false, srv.getArticle), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug", http.MethodPut, srv.handle(true, srv.updateArticle), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug", http.MethodDelete, srv.handle(true, srv.deleteArticle), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug/favorite", http.MethodPost, srv.handle(true, srv.favorite), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug/favorite", http.MethodDelete, srv.handle(true, srv.unfavorite), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug/comments", http.MethodGet, srv.handle(false, srv.listComments), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug/comments", http.MethodPost, srv.handle(true, srv.addComment), true),
+		patronhttp.NewRouteRaw("/api/articles/:slug/comments/:id", http.MethodDelete, srv.handle(true, srv.deleteComment), true),
+		patronhttp.NewRouteRaw("/api/tags", http.MethodGet, srv.handle(false, srv.tags), true),
+		patronhttp.NewRouteRaw(ResetPath, http.MethodPost, reset, true),
+	}, nil
+}
+
+// handle authenticates the request with the token of the Authorization header, in the "Token <token>"
+// form of the spec, and writes the response of the handler.
+func (srv server) handle(auth bool, h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		srv.store.Lock()
+		rsp := func() response {
+			defer srv.store.Unlock()
+			me, ok := srv.authenticate(r)
+			if !ok {
+				return fail(http.StatusUnauthorized, "token is not valid")
+			}
+			if auth && me == nil {
+				return fail(http.StatusUnauthorized, "authentication is required")
+			}
+			return h(r, me)
+		}()
+
+		if rsp.body == nil {
+			w.WriteHeader(rsp.code)
+			return
+		}
+		if err := srv.rnd.JSON(w, rsp.code, rsp.body); err != nil {
+			log.FromContext(r.Context()).Errorf("failed to write mock response: %v", err)
+		}
+	}
+}
+
+// authenticate returns the user of the request, or nil if it is anonymous.
+// It returns false if the request carries a token that is not valid.
+func (srv server) authenticate(r *http.Request) (*user, bool) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return nil, true
+	}
+	for _, scheme := range []string{"Token ", "Bearer "} {
+		if strings.HasPrefix(h, scheme) {
+			return srv.store.authenticate(strings.TrimPrefix(h, scheme))
+		}
+	}
+	return nil, false
+}
+
+func (srv server) login(r *http.Request, _ *user) response {
+	var req userRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	if req.User.Email == nil || req.User.Password == nil {
+		return fail(http.StatusUnprocessableEntity, "email and password are required")
+	}
+	u, ok := srv.store.login(*req.User.Email, *req.User.Password)
+	if !ok {
+		return fail(http.StatusUnauthorized, "email or password is invalid")
+	}
+	return ok200("user", viewUser(u))
+}
+
+func (srv server) register(r *http.Request, _ *user) response {
+	var req userRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	var msgs []string
+	for _, f := range []struct {
+		name string
+		v    *string
+	}{{"username", req.User.Username}, {"email", req.User.Email}, {"password", req.User.Password}} {
+		if f.v == nil || strings.TrimSpace(*f.v) == "" {
+			msgs = append(msgs, f.name+" can't be blank")
+		}
+	}
+	if len(msgs) > 0 {
+		return fail(http.StatusUnprocessableEntity, msgs...)
+	}
+	u, msgs := srv.store.register(*req.User.Username, *req.User.Email, *req.User.Password)
+	if len(msgs) > 0 {
+		return fail(http.StatusUnprocessableEntity, msgs...)
+	}
+	return response{code: http.StatusCreated, body: map[string]interface{}{"user": viewUser(u)}}
+}
+
+func (srv server) currentUser(_ *http.Request, me *user) response {
+	return ok200("user", viewUser(me))
+}
+
+func (srv server) updateUser(r *http.Request, me *user) response {
+	var req userRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	if req.User.Username != nil && *req.User.Username != me.Username {
+		return fail(http.StatusUnprocessableEntity, "username can't be changed on the mock server")
+	}
+	if req.User.Email != nil {
+		email := strings.TrimSpace(*req.User.Email)
+		if email == "" {
+			return fail(http.StatusUnprocessableEntity, "email can't be blank")
+		}
+		if srv.store.emailTaken(email, me) {
+			return fail(http.StatusUnprocessableEntity, "email has already been taken")
+		}
+		me.Email = email
+	}
+	if req.User.Password != nil {
+		me.Password = *req.User.Password
+	}
+	if req.User.Bio != nil {
+		me.Bio = *req.User.Bio
+	}
+	if req.User.Image != nil {
+		me.Image = *req.User.Image
+	}
+	return ok200("user", viewUser(me))
+}
+
+func (srv server) profile(r *http.Request, me *user) response {
+	u, ok := srv.store.users[param(r, "username")]
+	if !ok {
+		return fail(http.StatusNotFound, "profile not found")
+	}
+	return ok200("profile", srv.viewProfile(u, me))
+}
+
+func (srv server) follow(r *http.Request, me *user) response {
+	u, ok := srv.store.users[param(r, "username")]
+	if !ok {
+		return fail(http.StatusNotFound, "profile not found")
+	}
+	if u == me {
+		return fail(http.StatusUnprocessableEntity, "you can't follow yourself")
+	}
+	srv.store.follow(me.Username, u.Username)
+	return ok200("profile", srv.viewProfile(u, me))
+}
+
+func (srv server) unfollow(r *http.Request, me *user) response {
+	u, ok := srv.store.users[param(r, "username")]
+	if !ok {
+		return fail(http.StatusNotFound, "profile not found")
+	}
+	srv.store.unfollow(me.Username, u.Username)
+	return ok200("profile", srv.viewProfile(u, me))
+}
+
+func (srv server) listArticles(r *http.Request, me *user) response {
+	var q query.ArticleList
+	if err := query.Bind(query.Fields(r.URL.Query()), &q); err != nil {
+		return fail(http.StatusUnprocessableEntity, err.Error())
+	}
+	aa, total := srv.store.list(filter{Tag: q.Tag, Author: q.Author, Favorited: q.Favorited}, q.Limit, q.Offset)
+	return srv.articles(aa, total, me)
+}
+
+func (srv server) feed(r *http.Request, me *user) response {
+	if me == nil {
+		return fail(http.StatusUnauthorized, "authentication is required")
+	}
+	var q query.Page
+	if err := query.Bind(query.Fields(r.URL.Query()), &q); err != nil {
+		return fail(http.StatusUnprocessableEntity, err.Error())
+	}
+	aa, total := srv.store.list(filter{FeedOf: me.Username}, q.Limit, q.Offset)
+	return srv.articles(aa, total, me)
+}
+
+func (srv server) articles(aa []*article, total int, me *user) response {
+	vv := make([]articleView, 0, len(aa))
+	for _, a := range aa {
+		vv = append(vv, srv.viewArticle(a, me))
+	}
+	return response{code: http.StatusOK, body: map[string]interface{}{"articles": vv, "articlesCount": total}}
+}
+
+func (srv server) getArticle(r *http.Request, me *user) response {
+	sl := param(r, "slug")
+	if sl == feedSlug {
+		return srv.feed(r, me)
+	}
+	a, ok := srv.store.articles[sl]
+	if !ok {
+		return fail(http.StatusNotFound, "article not found")
+	}
+	return ok200("article", srv.viewArticle(a, me))
+}
+
+func (srv server) createArticle(r *http.Request, me *user) response {
+	var req articleRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	var msgs []string
+	if req.Article.Title == "" {
+		msgs = append(msgs, "title can't be blank")
+	}
+	if req.Article.Description == "" {
+		msgs = append(msgs, "description can't be blank")
+	}
+	if req.Article.Body == "" {
+		msgs = append(msgs, "body can't be blank")
+	}
+	if len(msgs) > 0 {
+		return fail(http.StatusUnprocessableEntity, msgs...)
+	}
+	a, err := srv.store.add(me.Username, req.Article.Title, req.Article.Description, req.Article.Body, req.Article.TagList)
+	if err != nil {
+		return fail(http.StatusUnprocessableEntity, err.Error())
+	}
+	return response{code: http.StatusCreated, body: map[string]interface{}{"article": srv.viewArticle(a, me)}}
+}
+
+func (srv server) updateArticle(r *http.Request, me *user) response {
+	var req articleRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	a, err := srv.store.owned(param(r, "slug"), me.Username)
+	if err != nil {
+		return failErr(err, "article")
+	}
+	a, err = srv.store.update(a, req.Article.Title, req.Article.Description, req.Article.Body)
+	if err != nil {
+		return fail(http.StatusUnprocessableEntity, err.Error())
+	}
+	return ok200("article", srv.viewArticle(a, me))
+}
+
+func (srv server) deleteArticle(r *http.Request, me *user) response {
+	a, err := srv.store.owned(param(r, "slug"), me.Username)
+	if err != nil {
+		return failErr(err, "article")
+	}
+	srv.store.delete(a)
+	return response{code: http.StatusOK}
+}
+
+func (srv server) favorite(r *http.Request, me *user) response {
+	a, ok := srv.store.articles[param(r, "slug")]
+	if !ok {
+		return fail(http.StatusNotFound, "article not found")
+	}
+	a.FavoritedBy[me.Username] = true
+	return ok200("article", srv.viewArticle(a, me))
+}
+
+func (srv server) unfavorite(r *http.Request, me *user) response {
+	a, ok := srv.store.articles[param(r, "slug")]
+	if !ok {
+		return fail(http.StatusNotFound, "article not found")
+	}
+	delete(a.FavoritedBy, me.Username)
+	return ok200("article", srv.viewArticle(a, me))
+}
+
+func (srv server) listComments(r *http.Request, me *user) response {
+	sl := param(r, "slug")
+	if _, ok := srv.store.articles[sl]; !ok {
+		return fail(http.StatusNotFound, "article not found")
+	}
+	cc := srv.store.articleComments(sl)
+	vv := make([]commentView, 0, len(cc))
+	for _, c := range cc {
+		vv = append(vv, srv.viewComment(c, me))
+	}
+	return ok200("comments", vv)
+}
+
+func (srv server) addComment(r *http.Request, me *user) response {
+	sl := param(r, "slug")
+	if _, ok := srv.store.articles[sl]; !ok {
+		return fail(http.StatusNotFound, "article not found")
+	}
+	var req commentRequest
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusUnprocessableEntity, "body is not valid")
+	}
+	if req.Comment.Body == "" {
+		return fail(http.StatusUnprocessableEntity, "body can't be blank")
+	}
+	c := srv.store.comment(sl, me.Username, req.Comment.Body)
+	return ok200("comment", srv.viewComment(c, me))
+}
+
+func (srv server) deleteComment(r *http.Request, me *user) response {
+	id, err := strconv.Atoi(param(r, "id"))
+	if err != nil {
+		return fail(http.StatusNotFound, "comment not found")
+	}
+	if err := srv.store.deleteComment(param(r, "slug"), id, me.Username); err != nil {
+		return failErr(err, "comment")
+	}
+	return response{code: http.StatusOK}
+}
+
+func (srv server) tags(_ *http.Request, _ *user) response {
+	return ok200("tags", srv.store.tags())
+}
+
+func viewUser(u *user) userView {
+	return userView{Email: u.Email, Token: Token(u.Username), Username: u.Username, Bio: u.Bio, Image: u.Image}
+}
+
+func (srv server) viewProfile(u *user, me *user) profileView {
+	p := profileView{Username: u.Username, Bio: u.Bio, Image: u.Image}
+	if me != nil {
+		p.Following = srv.store.following(me.Username, u.Username)
+	}
+	return p
+}
+
+func (srv server) viewArticle(a *article, me *user) articleView {
+	v := articleView{
+		Slug:           a.Slug,
+		Title:          a.Title,
+		Description:    a.Description,
+		Body:           a.Body,
+		TagList:        a.Tags,
+		CreatedAt:      render.Time(a.CreatedAt),
+		UpdatedAt:      render.Time(a.UpdatedAt),
+		FavoritesCount: len(a.FavoritedBy),
+		Author:         srv.viewProfile(srv.store.users[a.Author], me),
+	}
+	if me != nil {
+		v.Favorited = a.FavoritedBy[me.Username]
+	}
+	return v
+}
+
+func (srv server) viewComment(c *comment, me *user) commentView {
+	return commentView{
+		ID:        c.ID,
+		CreatedAt: render.Time(c.CreatedAt),
+		UpdatedAt: render.Time(c.CreatedAt),
+		Body:      c.Body,
+		Author:    srv.viewProfile(srv.store.users[c.Author], me),
+	}
+}
+
+func decode(r *http.Request, v interface{}) error {
+	return json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes)).Decode(v)
+}
+
+func param(r *http.Request, name string) string {
+	return httprouter.ParamsFromContext(r.Context()).ByName(name)
+}
+
+func ok200(name string, v interface{}) response {
+	return response{code: http.StatusOK, body: map[string]interface{}{name: v}}
+}
+
+func fail(code int, msgs ...string) response {
+	return response{code: code, body: apierror.NewBody(msgs...)}
+}
+
+func failErr(err error, what string) response {
+	if err == ErrForbidden {
+		return fail(http.StatusForbidden, "you can only change your own "+what)
+	}
+	return fail(http.StatusNotFound, what+" not found")
+}
@@ -0,0 +1,363 @@
+// Package mock serves the RealWorld API from deterministic in-memory fixtures, so that frontends and
+// contract tests can run against a faithful fake of the service without a database.
+package mock
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/slug"
+)
+
+const tokenPrefix = "mock-token-"
+
+var (
+	// ErrNotFound is returned if a user, article or comment does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned if a user changes an article or comment of another user.
+	ErrForbidden = errors.New("forbidden")
+)
+
+type user struct {
+	Username string
+	Email    string
+	Password string
+	Bio      string
+	Image    string
+}
+
+type article struct {
+	Slug        string
+	Title       string
+	Description string
+	Body        string
+	Tags        []string
+	Author      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FavoritedBy map[string]bool
+}
+
+type comment struct {
+	ID        int
+	Article   string
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// filter of the article lists. Empty values match all articles.
+type filter struct {
+	Tag       string
+	Author    string
+	Favorited string
+	// FeedOf lists only the articles of the authors followed by the user.
+	FeedOf string
+}
+
+// Store holds the state of the fake service. Writes change it until it is reset.
+type Store struct {
+	sync.Mutex
+	now         time.Time
+	users       map[string]*user
+	follows     map[string]map[string]bool
+	articles    map[string]*article
+	comments    []*comment
+	nextComment int
+}
+
+// NewStore creates a new store with the fixtures.
+func NewStore() *Store {
+	s := Store{}
+	s.reset()
+	return &s
+}
+
+// Reset restores the fixtures, discarding all writes.
+func (s *Store) Reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.reset()
+}
+
+func (s *Store) reset() {
+	s.now = epoch
+	s.users = make(map[string]*user)
+	s.follows = make(map[string]map[string]bool)
+	s.articles = make(map[string]*article)
+	s.comments = nil
+	s.nextComment = 1
+
+	for _, u := range fixtureUsers {
+		u := u
+		u.Password = Password
+		s.users[u.Username] = &u
+	}
+	for follower, ff := range fixtureFollows {
+		for _, f := range ff {
+			s.follow(follower, f)
+		}
+	}
+	for _, f := range fixtureArticles {
+		a, err := s.add(f.author, f.title, f.description, f.body, f.tags)
+		if err != nil {
+			panic("invalid fixture article: " + err.Error())
+		}
+		for _, u := range f.favoritedBy {
+			a.FavoritedBy[u] = true
+		}
+	}
+	for _, f := range fixtureComments {
+		s.comment(f.article, f.author, f.body)
+	}
+}
+
+// tick advances the clock of the store. It has to be called with the lock held.
+func (s *Store) tick() time.Time {
+	s.now = s.now.Add(time.Second)
+	return s.now
+}
+
+// Token returns the auth token of the user.
+func Token(username string) string {
+	return tokenPrefix + username
+}
+
+// authenticate returns the user of the token.
+func (s *Store) authenticate(token string) (*user, bool) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, false
+	}
+	u, ok := s.users[strings.TrimPrefix(token, tokenPrefix)]
+	return u, ok
+}
+
+func (s *Store) login(email, password string) (*user, bool) {
+	email = strings.TrimSpace(email)
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) && u.Password == password {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) register(username, email, password string) (*user, []string) {
+	var msgs []string
+	if _, ok := s.users[username]; ok {
+		msgs = append(msgs, "username has already been taken")
+	}
+	email = strings.TrimSpace(email)
+	if s.emailTaken(email, nil) {
+		msgs = append(msgs, "email has already been taken")
+	}
+	if len(msgs) > 0 {
+		return nil, msgs
+	}
+	u := &user{Username: username, Email: email, Password: password}
+	s.users[username] = u
+	return u, nil
+}
+
+// emailTaken returns true if the email, ignoring case, is used by a user other than the one given.
+func (s *Store) emailTaken(email string, other *user) bool {
+	for _, u := range s.users {
+		if u != other && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) following(follower, username string) bool {
+	return s.follows[follower][username]
+}
+
+func (s *Store) follow(follower, username string) {
+	ff, ok := s.follows[follower]
+	if !ok {
+		ff = make(map[string]bool)
+		s.follows[follower] = ff
+	}
+	ff[username] = true
+}
+
+func (s *Store) unfollow(follower, username string) {
+	delete(s.follows[follower], username)
+}
+
+// add creates an article with a unique slug. It has to be called with the lock held.
+// It fails if the title has no letters or digits to make a slug of.
+func (s *Store) add(author, title, description, body string, tags []string) (*article, error) {
+	sl, err := s.slug(title)
+	if err != nil {
+		return nil, err
+	}
+	now := s.tick()
+	a := &article{
+		Slug:        sl,
+		Title:       title,
+		Description: description,
+		Body:        body,
+		Tags:        tags,
+		Author:      author,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		FavoritedBy: make(map[string]bool),
+	}
+	s.articles[sl] = a
+	return a, nil
+}
+
+func (s *Store) slug(title string) (string, error) {
+	return slug.Unique(context.Background(), title, func(_ context.Context, sl string) (bool, error) {
+		_, ok := s.articles[sl]
+		return ok, nil
+	})
+}
+
+// owned returns the article of the slug if it is written by the user.
+func (s *Store) owned(sl, username string) (*article, error) {
+	a, ok := s.articles[sl]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if a.Author != username {
+		return nil, ErrForbidden
+	}
+	return a, nil
+}
+
+func (s *Store) update(a *article, title, description, body string) (*article, error) {
+	if title != "" && title != a.Title {
+		delete(s.articles, a.Slug)
+		sl, err := s.slug(title)
+		if err != nil {
+			s.articles[a.Slug] = a
+			return nil, err
+		}
+		old := a.Slug
+		a.Title = title
+		a.Slug = sl
+		s.articles[a.Slug] = a
+		for _, c := range s.comments {
+			if c.Article == old {
+				c.Article = a.Slug
+			}
+		}
+	}
+	if description != "" {
+		a.Description = description
+	}
+	if body != "" {
+		a.Body = body
+	}
+	a.UpdatedAt = s.tick()
+	return a, nil
+}
+
+func (s *Store) delete(a *article) {
+	delete(s.articles, a.Slug)
+	cc := s.comments[:0]
+	for _, c := range s.comments {
+		if c.Article != a.Slug {
+			cc = append(cc, c)
+		}
+	}
+	s.comments = cc
+}
+
+// list returns the matching articles, most recent first, and their total count.
+func (s *Store) list(f filter, limit, offset int) ([]*article, int) {
+	var aa []*article
+	for _, a := range s.articles {
+		if f.Author != "" && a.Author != f.Author {
+			continue
+		}
+		if f.Favorited != "" && !a.FavoritedBy[f.Favorited] {
+			continue
+		}
+		if f.Tag != "" && !contains(a.Tags, f.Tag) {
+			continue
+		}
+		if f.FeedOf != "" && !s.following(f.FeedOf, a.Author) {
+			continue
+		}
+		aa = append(aa, a)
+	}
+	sort.Slice(aa, func(i, j int) bool {
+		if aa[i].CreatedAt.Equal(aa[j].CreatedAt) {
+			return aa[i].Slug < aa[j].Slug
+		}
+		return aa[i].CreatedAt.After(aa[j].CreatedAt)
+	})
+	total := len(aa)
+	if offset >= total {
+		return nil, total
+	}
+	aa = aa[offset:]
+	if len(aa) > limit {
+		aa = aa[:limit]
+	}
+	return aa, total
+}
+
+func (s *Store) tags() []string {
+	seen := make(map[string]bool)
+	var tt []string
+	for _, a := range s.articles {
+		for _, t := range a.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tt = append(tt, t)
+			}
+		}
+	}
+	sort.Strings(tt)
+	return tt
+}
+
+// comment adds a comment to the article. It has to be called with the lock held.
+func (s *Store) comment(sl, author, body string) *comment {
+	c := &comment{ID: s.nextComment, Article: sl, Author: author, Body: body, CreatedAt: s.tick()}
+	s.nextComment++
+	s.comments = append(s.comments, c)
+	return c
+}
+
+func (s *Store) articleComments(sl string) []*comment {
+	var cc []*comment
+	for _, c := range s.comments {
+		if c.Article == sl {
+			cc = append(cc, c)
+		}
+	}
+	return cc
+}
+
+func (s *Store) deleteComment(sl string, id int, username string) error {
+	for i, c := range s.comments {
+		if c.Article != sl || c.ID != id {
+			continue
+		}
+		if c.Author != username {
+			return ErrForbidden
+		}
+		s.comments = append(s.comments[:i], s.comments[i+1:]...)
+		return nil
+	}
+	return ErrNotFound
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
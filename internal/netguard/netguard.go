@@ -0,0 +1,74 @@
+// Package netguard guards the connections made on behalf of clients, e.g. to the URLs of link previews
+// or webhooks, against reaching the internal network of the service.
+package netguard
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// blocked are the IP ranges that must not be reached on behalf of clients: loopback, private,
+// link-local (including the cloud metadata endpoints), carrier-grade NAT, multicast and reserved ranges,
+// and the NAT64 and 6to4 ranges, whose addresses embed IPv4 addresses that could be private.
+var blocked = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustParseCIDRs(cc ...string) []*net.IPNet {
+	nn := make([]*net.IPNet, 0, len(cc))
+	for _, c := range cc {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nn = append(nn, n)
+	}
+	return nn
+}
+
+// Blocked returns true if the IP is in a blocked range.
+func Blocked(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range blocked {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Control rejects connections to blocked IPs. It is meant for the Control of a net.Dialer, where it runs
+// after the name resolution, for every connection including redirects, so that DNS rebinding cannot bypass the check.
+func Control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || Blocked(ip) {
+		return errors.Errorf("address %s is not allowed", host)
+	}
+	return nil
+}
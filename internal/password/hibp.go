@@ -0,0 +1,74 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// HIBPURL is the URL of the HaveIBeenPwned range API.
+const HIBPURL = "https://api.pwnedpasswords.com/range/"
+
+// HIBPChecker checks passwords against the HaveIBeenPwned range API using k-anonymity:
+// only the first 5 characters of the SHA-1 hash of the password leave the service.
+type HIBPChecker struct {
+	url string
+	cl  *http.Client
+}
+
+// NewHIBPChecker creates a new checker for the range API at the url, e.g. HIBPURL.
+func NewHIBPChecker(url string, timeout time.Duration) (*HIBPChecker, error) {
+	if url == "" {
+		return nil, errors.New("url is required")
+	}
+	if timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	return &HIBPChecker{url: url, cl: &http.Client{Timeout: timeout}}, nil
+}
+
+// Breached returns the number of times the password appears in the breaches known to the API.
+func (c *HIBPChecker) Breached(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequest(http.MethodGet, c.url+prefix, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create request")
+	}
+	// padding hides the number of matching hashes from observers of the response size.
+	req.Header.Set("Add-Padding", "true")
+	rsp, err := c.cl.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query range api")
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("range api returned status %d", rsp.StatusCode)
+	}
+
+	sc := bufio.NewScanner(rsp.Body)
+	for sc.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(sc.Text()), ":", 2)
+		if len(parts) != 2 || parts[0] != suffix {
+			continue
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, errors.Wrap(err, "invalid count in range api response")
+		}
+		return n, nil
+	}
+	if err := sc.Err(); err != nil {
+		return 0, errors.Wrap(err, "failed to read range api response")
+	}
+	return 0, nil
+}
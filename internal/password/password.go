@@ -0,0 +1,136 @@
+// Package password validates user passwords against the password policy and known breaches.
+package password
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// Policy definition of the passwords accepted on registration and password change.
+// MinEntropy is the minimum estimated entropy in bits, based on the length and the character classes used.
+type Policy struct {
+	MinLength  int
+	MaxLength  int
+	MinEntropy float64
+}
+
+// DefaultPolicy returns the default password policy.
+func DefaultPolicy() Policy {
+	return Policy{MinLength: 8, MaxLength: 128, MinEntropy: 40}
+}
+
+// BreachChecker interface for checking whether a password has appeared in a data breach.
+// It returns the number of times the password has been seen.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (int, error)
+}
+
+// OptionFunc defines an option func for the validator.
+type OptionFunc func(*Validator) error
+
+// Breaches option for rejecting passwords found by the breach checker.
+func Breaches(c BreachChecker) OptionFunc {
+	return func(v *Validator) error {
+		if c == nil {
+			return errors.New("breach checker is nil")
+		}
+		v.checker = c
+		return nil
+	}
+}
+
+// Validator validates passwords against a policy and, optionally, a breach checker.
+type Validator struct {
+	policy  Policy
+	checker BreachChecker
+}
+
+// NewValidator creates a new validator for the policy.
+func NewValidator(p Policy, oo ...OptionFunc) (*Validator, error) {
+	if p.MinLength <= 0 || p.MaxLength < p.MinLength {
+		return nil, errors.New("invalid password length limits")
+	}
+	if p.MinEntropy < 0 {
+		return nil, errors.New("min entropy must not be negative")
+	}
+	v := Validator{policy: p}
+	for _, o := range oo {
+		err := o(&v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// Validate checks the password and returns a 422 validation error with the violations.
+// Failures of the breach checker are logged and the password is accepted,
+// so that an outage of the breach service does not block registrations.
+func (v *Validator) Validate(ctx context.Context, password string) error {
+	var msgs []string
+	n := utf8.RuneCountInString(password)
+	switch {
+	case n < v.policy.MinLength:
+		msgs = append(msgs, fmt.Sprintf("password is too short (minimum is %d characters)", v.policy.MinLength))
+	case n > v.policy.MaxLength:
+		msgs = append(msgs, fmt.Sprintf("password is too long (maximum is %d characters)", v.policy.MaxLength))
+	}
+	if Entropy(password) < v.policy.MinEntropy {
+		msgs = append(msgs, "password is too weak, use a longer password or mix letters, digits and symbols")
+	}
+	if len(msgs) > 0 {
+		return apierror.Validation(msgs...)
+	}
+
+	if v.checker == nil {
+		return nil
+	}
+	count, err := v.checker.Breached(ctx, password)
+	if err != nil {
+		log.FromContext(ctx).Warnf("failed to check password breaches: %v", err)
+		return nil
+	}
+	if count > 0 {
+		return apierror.Validation("password has appeared in a data breach, choose a different password")
+	}
+	return nil
+}
+
+// Entropy estimates the entropy of the password in bits from its length and the size of the character classes used.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLower(r):
+			lower = true
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			upper = true
+		case r < utf8.RuneSelf && unicode.IsDigit(r):
+			digit = true
+		case r < utf8.RuneSelf:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	pool := 0
+	for _, c := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.used {
+			pool += c.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
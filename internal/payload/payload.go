@@ -0,0 +1,49 @@
+// Package payload decodes the JSON request bodies of the RealWorld API strictly.
+package payload
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// errBodyTooLarge is the message of the error returned by http.MaxBytesReader.
+const errBodyTooLarge = "http: request body too large"
+
+// Decode decodes a single JSON value from r into v, rejecting unknown fields and trailing data
+// with a 422 validation error. Bodies exceeding the limit of the body limit middleware
+// are rejected with a 413.
+func Decode(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		return decodeError(err)
+	}
+	_, err = dec.Token()
+	if err != io.EOF {
+		if err != nil {
+			return decodeError(err)
+		}
+		return apierror.Validation("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+func decodeError(err error) *patronhttp.Error {
+	switch {
+	case err == io.EOF:
+		return apierror.Validation("request body is empty")
+	case err.Error() == errBodyTooLarge:
+		return apierror.New(http.StatusRequestEntityTooLarge, "request body too large")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return apierror.Validation("unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return apierror.Validation("request body is not valid JSON: " + err.Error())
+	}
+}
@@ -0,0 +1,172 @@
+// Package query binds and validates the query params of the RealWorld API into typed request structs.
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// formats are the named formats of the format tag.
+var formats = map[string]*regexp.Regexp{
+	"username": regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,39}$`),
+	"tag":      regexp.MustCompile(`^[^,\x00-\x1f]{1,64}$`),
+	"slug":     regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{Nd}]+(-[\p{Ll}\p{Lo}\p{Nd}]+)*$`),
+}
+
+// Page is the pagination of the list endpoints, 20 items by default and at most 100.
+type Page struct {
+	Limit  int `query:"limit" default:"20" min:"1" max:"100"`
+	Offset int `query:"offset" default:"0" min:"0"`
+}
+
+// ArticleList is the query of GET /api/articles.
+type ArticleList struct {
+	Page
+	Tag       string `query:"tag" format:"tag"`
+	Author    string `query:"author" format:"username"`
+	Favorited string `query:"favorited" format:"username"`
+}
+
+// Valid returns true if the value has the named format, e.g. username or tag.
+// Unknown formats are never valid.
+func Valid(format, v string) bool {
+	re, ok := formats[format]
+	return ok && re.MatchString(v)
+}
+
+// Fields returns the first value of every query param, like the fields of a patron request.
+func Fields(q url.Values) map[string]string {
+	ff := make(map[string]string, len(q))
+	for k, vv := range q {
+		if len(vv) > 0 {
+			ff[k] = vv[0]
+		}
+	}
+	return ff
+}
+
+// Bind sets the fields of the struct pointed to by v from the query params, e.g. the fields of a patron request.
+// The fields are selected by the query tag, and validated by the optional default, min, max and format tags.
+// Fields of embedded structs are bound too. Supported field types are string, bool, int and int64.
+// All invalid params are reported in a single 422 error.
+func Bind(fields map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind target must be a pointer to a struct")
+	}
+	var msgs []string
+	if err := bind(fields, rv.Elem(), &msgs); err != nil {
+		return err
+	}
+	if len(msgs) > 0 {
+		return apierror.Validation(msgs...)
+	}
+	return nil
+}
+
+func bind(fields map[string]string, sv reflect.Value, msgs *[]string) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		fv := sv.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := bind(fields, fv, msgs); err != nil {
+				return err
+			}
+			continue
+		}
+		name := f.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		raw, ok := fields[name]
+		if !ok || raw == "" {
+			raw, ok = f.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+		}
+		msg, err := set(f, fv, name, raw)
+		if err != nil {
+			return err
+		}
+		if msg != "" {
+			*msgs = append(*msgs, msg)
+		}
+	}
+	return nil
+}
+
+// set sets the field from the raw value, returning a validation message if the value is not valid.
+func set(f reflect.StructField, fv reflect.Value, name, raw string) (string, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		if format := f.Tag.Get("format"); format != "" {
+			re, ok := formats[format]
+			if !ok {
+				return "", errors.Errorf("unknown format %q of query param %s", format, name)
+			}
+			if !re.MatchString(raw) {
+				return fmt.Sprintf("%s is not a valid %s", name, format), nil
+			}
+		}
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return name + " must be true or false", nil
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return name + " must be an integer", nil
+		}
+		if msg, err := bounds(f, name, n); msg != "" || err != nil {
+			return msg, err
+		}
+		fv.SetInt(n)
+	default:
+		return "", errors.Errorf("query param %s has unsupported type %s", name, fv.Type())
+	}
+	return "", nil
+}
+
+func bounds(f reflect.StructField, name string, n int64) (string, error) {
+	min, hasMin, err := intTag(f, "min")
+	if err != nil {
+		return "", err
+	}
+	max, hasMax, err := intTag(f, "max")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case hasMin && hasMax && (n < min || n > max):
+		return fmt.Sprintf("%s must be between %d and %d", name, min, max), nil
+	case hasMin && n < min:
+		return fmt.Sprintf("%s must be at least %d", name, min), nil
+	case hasMax && n > max:
+		return fmt.Sprintf("%s must be at most %d", name, max), nil
+	}
+	return "", nil
+}
+
+func intTag(f reflect.StructField, key string) (int64, bool, error) {
+	v, ok := f.Tag.Lookup(key)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "%s tag of field %s is not valid", key, f.Name)
+	}
+	return n, true, nil
+}
@@ -0,0 +1,148 @@
+// Package quota enforces per-user quotas (e.g. articles per day, comments per hour) on top of burst rate limiting.
+package quota
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+const (
+	// ArticleClass is the quota class for creating articles.
+	ArticleClass = "articles"
+	// CommentClass is the quota class for creating comments.
+	CommentClass = "comments"
+
+	// DefaultRole is the role used when the policy has no limits for the role of the user.
+	DefaultRole = "user"
+)
+
+// Limit definition of a quota as a maximum number of operations within a window.
+type Limit struct {
+	Max    int
+	Window time.Duration
+}
+
+// Policy maps roles to the limits of each quota class.
+type Policy map[string]map[string]Limit
+
+// DefaultPolicy returns the default quotas of regular users. Admins are not limited.
+func DefaultPolicy() Policy {
+	return Policy{
+		DefaultRole: {
+			ArticleClass: {Max: 20, Window: 24 * time.Hour},
+			CommentClass: {Max: 100, Window: time.Hour},
+		},
+		"admin": {},
+	}
+}
+
+// Status of a quota after an operation has been accounted for.
+type Status struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Headers returns the quota headers to be set on the response.
+func (s Status) Headers() map[string]string {
+	return map[string]string{
+		"X-Quota-Limit":     strconv.Itoa(s.Limit),
+		"X-Quota-Remaining": strconv.Itoa(s.Remaining),
+		"X-Quota-Reset":     strconv.FormatInt(s.Reset.Unix(), 10),
+	}
+}
+
+// ExceededError is returned when a quota is exhausted.
+type ExceededError struct {
+	Class  string
+	Status Status
+}
+
+// Error returns the message of the error.
+func (e *ExceededError) Error() string {
+	return "quota exceeded for " + e.Class
+}
+
+// HTTPError converts the error to a 429 patron HTTP error with a RealWorld error body.
+func (e *ExceededError) HTTPError() *patronhttp.Error {
+	return apierror.New(http.StatusTooManyRequests, e.Error())
+}
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// Enforcer keeps fixed-window counters per user and quota class.
+type Enforcer struct {
+	sync.Mutex
+	policy   Policy
+	counters map[string]*window
+	now      func() time.Time
+}
+
+// NewEnforcer creates a new enforcer for the policy.
+func NewEnforcer(p Policy) (*Enforcer, error) {
+	if len(p) == 0 {
+		return nil, errors.New("policy is empty")
+	}
+	for role, ll := range p {
+		for class, l := range ll {
+			if l.Max <= 0 || l.Window <= 0 {
+				return nil, errors.Errorf("invalid limit for role %s and class %s", role, class)
+			}
+		}
+	}
+	return &Enforcer{policy: p, counters: make(map[string]*window), now: time.Now}, nil
+}
+
+// Allow accounts for an operation of the user on the quota class.
+// It returns an ExceededError if the quota is exhausted; the operation is not counted in that case.
+// Classes without a limit for the role are always allowed and return a zero status.
+func (e *Enforcer) Allow(userID, role, class string) (Status, error) {
+	ll, ok := e.policy[role]
+	if !ok {
+		ll = e.policy[DefaultRole]
+	}
+	l, ok := ll[class]
+	if !ok {
+		return Status{}, nil
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	now := e.now()
+	key := class + ":" + userID
+	w, ok := e.counters[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(l.Window)}
+		e.counters[key] = w
+	}
+
+	st := Status{Limit: l.Max, Reset: w.reset}
+	if w.count >= l.Max {
+		return st, &ExceededError{Class: class, Status: st}
+	}
+	w.count++
+	st.Remaining = l.Max - w.count
+	return st, nil
+}
+
+// Prune removes expired counters. It is meant to be run periodically.
+func (e *Enforcer) Prune() {
+	e.Lock()
+	defer e.Unlock()
+	now := e.now()
+	for k, w := range e.counters {
+		if !now.Before(w.reset) {
+			delete(e.counters, k)
+		}
+	}
+}
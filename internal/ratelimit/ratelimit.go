@@ -0,0 +1,246 @@
+// Package ratelimit limits the request rate of clients with token buckets.
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per key, refilled at rate tokens per second up to burst.
+type Limiter struct {
+	sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// New creates a new limiter allowing rate requests per second per key, with bursts of up to burst requests.
+func New(rate float64, burst int) (*Limiter, error) {
+	if rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	if burst <= 0 {
+		return nil, errors.New("burst must be positive")
+	}
+	return &Limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), now: time.Now}, nil
+}
+
+// Allow takes n tokens of the key. If there are not enough tokens nothing is taken,
+// and the time until they are available is returned.
+func (l *Limiter) Allow(key string, n int) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if float64(n) > b.tokens {
+		missing := float64(n) - b.tokens
+		return false, time.Duration(missing / l.rate * float64(time.Second))
+	}
+	b.tokens -= float64(n)
+	return true, 0
+}
+
+// Prune removes the buckets that are full again. It is meant to be run periodically.
+func (l *Limiter) Prune() {
+	l.Lock()
+	defer l.Unlock()
+	now := l.now()
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// KeyFunc returns the key a request is limited by.
+type KeyFunc func(r *http.Request) string
+
+// ClientIP returns the IP of the client of the request, as seen by the server.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Proxies are the networks of the trusted proxies in front of the service, e.g. the API gateway and the load balancer.
+type Proxies []*net.IPNet
+
+// ParseProxies parses the networks of the trusted proxies, e.g. "10.0.0.0/8". Single IPs are accepted as well.
+func ParseProxies(cidrs ...string) (Proxies, error) {
+	var pp Proxies
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if !strings.Contains(c, "/") {
+			if strings.Contains(c, ":") {
+				c += "/128"
+			} else {
+				c += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "trusted proxy %s is not valid", c)
+		}
+		pp = append(pp, n)
+	}
+	return pp, nil
+}
+
+// ClientIP returns the IP of the client of the request. Requests from the trusted proxies are attributed to
+// the address the first of them received the request from, read from the right of the X-Forwarded-For header,
+// since the entries on its left are set by the client.
+func (pp Proxies) ClientIP(r *http.Request) string {
+	ip := ClientIP(r)
+	if !pp.trusted(ip) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !pp.trusted(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func (pp Proxies) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range pp {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware creates a MiddlewareFunc taking a token per request of the key,
+// and rejecting requests without tokens with a 429 and a Retry-After header.
+func Middleware(l *Limiter, key KeyFunc) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := l.Allow(key(r), 1)
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				apierror.Write(w, http.StatusTooManyRequests, "too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClassesOptionFunc defines an option func for the rate limit classes.
+type ClassesOptionFunc func(*Classes) error
+
+// TrustedProxies option for limiting the requests forwarded by the proxies by the client IP of their
+// X-Forwarded-For header, instead of by the IP of the proxy.
+func TrustedProxies(cidrs ...string) ClassesOptionFunc {
+	return func(c *Classes) error {
+		pp, err := ParseProxies(cidrs...)
+		if err != nil {
+			return err
+		}
+		c.proxies = append(c.proxies, pp...)
+		return nil
+	}
+}
+
+// Classes holds the limiters of the rate limit classes of the routes, limiting the requests of every
+// class per client IP.
+type Classes struct {
+	limiters map[string]*Limiter
+	proxies  Proxies
+}
+
+// NewClasses creates a new set of rate limit classes.
+func NewClasses(oo ...ClassesOptionFunc) (*Classes, error) {
+	c := Classes{limiters: make(map[string]*Limiter)}
+	for _, o := range oo {
+		err := o(&c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// NewClassesFromEnv creates a new set of rate limit classes, trusting the X-Forwarded-For header of the
+// comma separated networks of REALWORLD_TRUSTED_PROXIES. Without them every request is limited by the
+// IP it comes from, which behind a load balancer is the same for all clients.
+func NewClassesFromEnv() (*Classes, error) {
+	var oo []ClassesOptionFunc
+	if v := os.Getenv("REALWORLD_TRUSTED_PROXIES"); v != "" {
+		oo = append(oo, TrustedProxies(strings.Split(v, ",")...))
+	}
+	c, err := NewClasses(oo...)
+	if err != nil {
+		return nil, errors.Wrap(err, "env var for trusted proxies is not valid")
+	}
+	return c, nil
+}
+
+// Add adds a class allowing rate requests per second per client, with bursts of up to burst requests.
+func (c *Classes) Add(class string, rate float64, burst int) error {
+	if class == "" {
+		return errors.New("class is required")
+	}
+	if _, ok := c.limiters[class]; ok {
+		return errors.Errorf("class %s is already added", class)
+	}
+	l, err := New(rate, burst)
+	if err != nil {
+		return errors.Wrapf(err, "class %s", class)
+	}
+	c.limiters[class] = l
+	return nil
+}
+
+// Middleware returns the rate limiting middleware of the class, or nil if the class does not exist.
+func (c *Classes) Middleware(class string) patronhttp.MiddlewareFunc {
+	l, ok := c.limiters[class]
+	if !ok {
+		return nil
+	}
+	return Middleware(l, c.proxies.ClientIP)
+}
+
+// Prune removes the buckets that are full again from the limiters of all classes. It is meant to be run periodically.
+func (c *Classes) Prune() {
+	for _, l := range c.limiters {
+		l.Prune()
+	}
+}
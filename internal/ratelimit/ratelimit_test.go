@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxiesClientIP(t *testing.T) {
+	pp, err := ParseProxies("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatalf("ParseProxies() error: %v", err)
+	}
+	tests := map[string]struct {
+		remote string
+		xff    []string
+		want   string
+	}{
+		"direct client":          {remote: "203.0.113.7:1234", want: "203.0.113.7"},
+		"direct client spoofing": {remote: "203.0.113.7:1234", xff: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		"proxy without header":   {remote: "10.0.0.1:1234", want: "10.0.0.1"},
+		"through proxy":          {remote: "10.0.0.1:1234", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		"through proxies":        {remote: "10.0.0.1:1234", xff: []string{"198.51.100.1, 192.168.1.1"}, want: "198.51.100.1"},
+		"spoofed by client":      {remote: "10.0.0.1:1234", xff: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		"multiple headers":       {remote: "10.0.0.1:1234", xff: []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		"garbage before client":  {remote: "10.0.0.1:1234", xff: []string{"unknown, 198.51.100.1"}, want: "198.51.100.1"},
+		"garbage from proxy":     {remote: "10.0.0.1:1234", xff: []string{"198.51.100.1, unknown"}, want: "10.0.0.1"},
+		"only proxies":           {remote: "10.0.0.1:1234", xff: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/unfurl", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := pp.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassesLimitByForwardedClient(t *testing.T) {
+	c, err := NewClasses(TrustedProxies("10.0.0.0/8"))
+	if err != nil {
+		t.Fatalf("NewClasses() error: %v", err)
+	}
+	if err := c.Add("unfurl", 1, 1); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	h := c.Middleware("unfurl")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(client string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/unfurl", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", client)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if code := serve("198.51.100.1"); code != http.StatusOK {
+		t.Errorf("status of the first request = %d, want %d", code, http.StatusOK)
+	}
+	if code := serve("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("status of the second request of the client = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := serve("198.51.100.2"); code != http.StatusOK {
+		t.Errorf("status of another client behind the same proxy = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestParseProxiesInvalid(t *testing.T) {
+	if _, err := NewClasses(TrustedProxies("10.0.0.0/33")); err == nil {
+		t.Error("NewClasses() with an invalid proxy network succeeded")
+	}
+}
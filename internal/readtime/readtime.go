@@ -0,0 +1,124 @@
+// Package readtime computes the word count and the estimated reading time of the articles.
+package readtime
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/scheduler"
+)
+
+const (
+	defaultWPM = 230
+	// cjkCPM is the reading speed of Chinese, Japanese and Korean, counted in characters per minute,
+	// since these languages do not separate words with spaces.
+	cjkCPM = 400
+)
+
+// wpm are the average silent reading speeds in words per minute per language.
+var wpm = map[string]int{
+	"en": 238,
+	"de": 179,
+	"es": 218,
+	"fr": 195,
+	"it": 188,
+	"pt": 181,
+	"ru": 184,
+	"el": 200,
+}
+
+var (
+	codeBlock = regexp.MustCompile("(?s)```.*?```")
+	markup    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)|[#*_>~` + "`" + `|-]`)
+)
+
+// Stats of an article.
+type Stats struct {
+	Words       int `json:"wordCount"`
+	ReadingTime int `json:"readingTime"`
+}
+
+// Compute returns the stats of the markdown body in the language, e.g. de.
+// Code blocks are skipped, and the reading time is rounded up to whole minutes, with a minimum of one.
+func Compute(body, lang string) Stats {
+	text := codeBlock.ReplaceAllString(body, " ")
+	text = markup.ReplaceAllString(text, "$1 ")
+
+	words, cjk := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+
+	speed, ok := wpm[language(lang)]
+	if !ok {
+		speed = defaultWPM
+	}
+	minutes := float64(words)/float64(speed) + float64(cjk)/cjkCPM
+	return Stats{Words: words + cjk, ReadingTime: int(math.Max(1, math.Ceil(minutes)))}
+}
+
+func language(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// Article whose stats have not been computed yet.
+type Article struct {
+	ID   string
+	Body string
+	Lang string
+}
+
+// Store interface for backfilling the stats of the existing articles.
+type Store interface {
+	// WithoutStats returns up to limit articles without stats.
+	WithoutStats(ctx context.Context, limit int) ([]Article, error)
+	// SaveStats stores the stats of an article.
+	SaveStats(ctx context.Context, id string, s Stats) error
+}
+
+// BackfillJob returns a scheduler job computing the stats of up to batch articles without stats per run.
+func BackfillJob(s Store, interval time.Duration, batch int) (scheduler.Job, error) {
+	if s == nil {
+		return scheduler.Job{}, errors.New("store is nil")
+	}
+	if batch <= 0 {
+		return scheduler.Job{}, errors.New("batch must be positive")
+	}
+	run := func(ctx context.Context) error {
+		aa, err := s.WithoutStats(ctx, batch)
+		if err != nil {
+			return errors.Wrap(err, "failed to get articles without stats")
+		}
+		for _, a := range aa {
+			if err := s.SaveStats(ctx, a.ID, Compute(a.Body, a.Lang)); err != nil {
+				return errors.Wrapf(err, "failed to save stats of article %s", a.ID)
+			}
+		}
+		if len(aa) > 0 {
+			log.Infof("backfilled reading stats of %d articles", len(aa))
+		}
+		return nil
+	}
+	return scheduler.Job{Name: "readtime-backfill", Interval: interval, Run: run}, nil
+}
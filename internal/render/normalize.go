@@ -0,0 +1,169 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// maxDepth bounds the traversal of emptyLists, protecting against cyclic values.
+const maxDepth = 32
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// emptyLists returns a copy of v in which nil slices are replaced by empty ones, so that they are
+// encoded as [] instead of null. Values implementing json.Marshaler are kept as they are.
+func emptyLists(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return normalize(reflect.ValueOf(v), 0).Interface()
+}
+
+func normalize(v reflect.Value, depth int) reflect.Value {
+	if depth > maxDepth || v.Type().Implements(marshalerType) {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(normalize(v.Elem(), depth+1))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(normalize(v.Elem(), depth+1))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			c.Field(i).Set(normalize(v.Field(i), depth+1))
+		}
+		return c
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(normalize(v.Index(i), depth+1))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, normalize(v.MapIndex(k), depth+1))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// recase re-encodes the JSON document, converting the keys of all objects with f.
+func recase(b []byte, f func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var (
+		out bytes.Buffer
+		// stack holds the open containers.
+		stack []*frame
+	)
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to recase response")
+		}
+
+		if d, ok := t.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(d))
+			valueDone(stack)
+			continue
+		}
+		if out.Len() > 0 {
+			switch out.Bytes()[out.Len()-1] {
+			case '[', '{', ':':
+			default:
+				out.WriteByte(',')
+			}
+		}
+
+		switch tv := t.(type) {
+		case json.Delim:
+			out.WriteByte(byte(tv))
+			stack = append(stack, &frame{object: tv == '{', key: tv == '{'})
+		case string:
+			isKey := len(stack) > 0 && stack[len(stack)-1].key
+			if isKey {
+				tv = f(tv)
+			}
+			sb, _ := json.Marshal(tv)
+			out.Write(sb)
+			if isKey {
+				out.WriteByte(':')
+				stack[len(stack)-1].key = false
+				continue
+			}
+			valueDone(stack)
+		default:
+			vb, _ := json.Marshal(tv)
+			out.Write(vb)
+			valueDone(stack)
+		}
+	}
+}
+
+// frame is an open JSON container.
+type frame struct {
+	object bool
+	// key is true if the next token of an object is a key.
+	key bool
+}
+
+// valueDone marks that the next token of the enclosing container is a key, if it is an object.
+func valueDone(stack []*frame) {
+	if len(stack) > 0 && stack[len(stack)-1].object {
+		stack[len(stack)-1].key = true
+	}
+}
+
+// snakeCase converts a camelCase key to snake_case, keeping acronyms together, e.g. articlesCount
+// to articles_count and createdAtUTC to created_at_utc.
+func snakeCase(s string) string {
+	rr := []rune(s)
+	var b strings.Builder
+	for i, r := range rr {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(rr[i-1]) || unicode.IsDigit(rr[i-1]))
+			nextLower := i > 0 && i+1 < len(rr) && unicode.IsLower(rr[i+1]) && unicode.IsUpper(rr[i-1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,181 @@
+// Package render writes the JSON responses of the RealWorld API consistently: with a charset,
+// RealWorld timestamps, empty arrays instead of null and streamed lists.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// ContentType of the JSON responses.
+const ContentType = "application/json; charset=utf-8"
+
+// TimeFormat is the RealWorld timestamp format, ISO 8601 in UTC with milliseconds.
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// Time is a time encoded in JSON in the RealWorld timestamp format.
+type Time time.Time
+
+// MarshalJSON encodes the time in UTC with milliseconds.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(t).UTC().Format(TimeFormat) + `"`), nil
+}
+
+// UnmarshalJSON decodes an RFC 3339 time.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var v time.Time
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*t = Time(v)
+	return nil
+}
+
+// Casing converts the keys of the JSON objects.
+type Casing int
+
+const (
+	// CamelCase keeps the keys of the json tags, which are camelCase in the RealWorld spec.
+	CamelCase Casing = iota
+	// SnakeCase converts the keys to snake_case, e.g. for clients following other API conventions.
+	SnakeCase
+)
+
+// OptionFunc defines an option func for the renderer.
+type OptionFunc func(*Renderer) error
+
+// KeyCasing option for setting the casing of the object keys.
+func KeyCasing(c Casing) OptionFunc {
+	return func(r *Renderer) error {
+		if c != CamelCase && c != SnakeCase {
+			return errors.New("unknown casing")
+		}
+		r.casing = c
+		return nil
+	}
+}
+
+// NullLists option for rendering nil slices as null, like encoding/json does, instead of empty arrays.
+func NullLists() OptionFunc {
+	return func(r *Renderer) error {
+		r.nullLists = true
+		return nil
+	}
+}
+
+// FlushEvery option for setting the number of streamed list items after which the response is flushed.
+func FlushEvery(n int) OptionFunc {
+	return func(r *Renderer) error {
+		if n <= 0 {
+			return errors.New("flush interval must be positive")
+		}
+		r.flushEvery = n
+		return nil
+	}
+}
+
+// Renderer writes JSON responses.
+type Renderer struct {
+	casing     Casing
+	nullLists  bool
+	flushEvery int
+}
+
+// New creates a new renderer. By default keys keep their casing, nil slices are rendered
+// as empty arrays and streamed lists are flushed every 100 items.
+func New(oo ...OptionFunc) (*Renderer, error) {
+	r := Renderer{flushEvery: 100}
+	for _, o := range oo {
+		err := o(&r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// Marshal encodes v as JSON with the options of the renderer.
+func (r *Renderer) Marshal(v interface{}) ([]byte, error) {
+	if !r.nullLists {
+		v = emptyLists(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode response")
+	}
+	if r.casing == SnakeCase {
+		return recase(b, snakeCase)
+	}
+	return b, nil
+}
+
+// JSON writes v as the JSON body of the response with the status code.
+func (r *Renderer) JSON(w http.ResponseWriter, code int, v interface{}) error {
+	b, err := r.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(code)
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// NextFunc returns the next item of a streamed list, or false when there are no more items.
+type NextFunc func() (interface{}, bool, error)
+
+// List streams a list response of the RealWorld form {"<name>": [...], "<name>Count": count}, e.g. articles,
+// encoding and flushing the items as they are returned, so that large lists are not buffered.
+// Since the status is sent before the items, an error of next aborts the response, leaving it invalid.
+func (r *Renderer) List(w http.ResponseWriter, name string, count int, next NextFunc) error {
+	countKey := name + "Count"
+	if r.casing == SnakeCase {
+		name, countKey = snakeCase(name), snakeCase(name)+"_count"
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	f, _ := w.(http.Flusher)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"` + name + `":[`)
+	for i := 0; ; i++ {
+		v, ok, err := next()
+		if err != nil {
+			return errors.Wrap(err, "failed to get the next list item")
+		}
+		if !ok {
+			break
+		}
+		b, err := r.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(b)
+		if (i+1)%r.flushEvery == 0 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+			if f != nil {
+				f.Flush()
+			}
+		}
+	}
+	buf.WriteString(`],"` + countKey + `":`)
+	buf.WriteString(jsonInt(count))
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func jsonInt(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package route
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/signing"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var deprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "http",
+	Name:      "deprecated_requests_total",
+	Help:      "Number of requests to deprecated routes, by route and consumer.",
+}, []string{"route", "consumer"})
+
+func init() {
+	prometheus.MustRegister(deprecatedRequests)
+}
+
+// Deprecation of a route, announced to the callers with the Deprecation (RFC 9745), Sunset (RFC 8594)
+// and Link headers.
+type Deprecation struct {
+	// Since is the time the route was deprecated.
+	Since time.Time
+	// Sunset is the time the route will be removed. It is optional until the date is decided.
+	Sunset time.Time
+	// Link is the URL documenting the deprecation and the replacement of the route. It is optional.
+	Link string
+}
+
+func (d Deprecation) validate() error {
+	if d.Since.IsZero() {
+		return errors.New("deprecation requires the since time")
+	}
+	if !d.Sunset.IsZero() && d.Sunset.Before(d.Since) {
+		return errors.New("sunset is before the deprecation")
+	}
+	if d.Link != "" {
+		u, err := url.Parse(d.Link)
+		if err != nil || !u.IsAbs() {
+			return errors.Errorf("deprecation link %q is not an absolute URL", d.Link)
+		}
+	}
+	return nil
+}
+
+// ConsumerFunc returns the consumer of a request, used as a metric label, so it has to have a low cardinality.
+type ConsumerFunc func(r *http.Request) string
+
+// DefaultConsumer returns the internal service that signed the request, or anonymous.
+func DefaultConsumer(r *http.Request) string {
+	if c, ok := signing.Caller(r.Context()); ok {
+		return c
+	}
+	return "anonymous"
+}
+
+// deprecated creates a MiddlewareFunc announcing the deprecation of the route and counting its requests by consumer.
+func deprecated(route string, d Deprecation, consumer ConsumerFunc) patronhttp.MiddlewareFunc {
+	since := "@" + strconv.FormatInt(d.Since.Unix(), 10)
+	var sunset string
+	if !d.Sunset.IsZero() {
+		sunset = d.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", since)
+			if sunset != "" {
+				h.Set("Sunset", sunset)
+			}
+			if d.Link != "" {
+				h.Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+			}
+			deprecatedRequests.WithLabelValues(route, consumer(r)).Inc()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package route provides helpers for building the patron routes of the RealWorld API.
+package route
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+)
+
+const (
+	// APIPrefix is the unversioned prefix of the RealWorld API, aliased to the latest stable version.
+	APIPrefix = "/api"
+	// V1Prefix is the prefix of version 1 of the API.
+	V1Prefix = APIPrefix + "/v1"
+)
+
+type spec struct {
+	pattern   string
+	method    string
+	processor sync.ProcessorFunc
+	handler   http.HandlerFunc
+	trace     bool
+	auth      auth.Authenticator
+	// beforeAuth are created from the full path template and run before the authentication of the route.
+	beforeAuth  []PatternMiddlewareFunc
+	middlewares []patronhttp.MiddlewareFunc
+}
+
+// Group is a set of routes sharing a path prefix and a list of middlewares.
+// A group can be served under additional prefixes by using aliases, which allows
+// the unversioned API to be served by the latest version without duplicating the wiring.
+type Group struct {
+	prefixes    []string
+	middlewares []patronhttp.MiddlewareFunc
+	perRoute    []PatternMiddlewareFunc
+	specs       []spec
+}
+
+// PatternMiddlewareFunc creates a middleware for a route from its full path template, e.g. for access logging.
+type PatternMiddlewareFunc func(pattern string) patronhttp.MiddlewareFunc
+
+// NewGroup creates a new route group for the prefix with middlewares applied to every route of the group.
+func NewGroup(prefix string, mm ...patronhttp.MiddlewareFunc) *Group {
+	return &Group{prefixes: []string{cleanPrefix(prefix)}, middlewares: mm}
+}
+
+// NewV1 creates the version 1 route group, which is also served under the unversioned API prefix.
+func NewV1(mm ...patronhttp.MiddlewareFunc) *Group {
+	return NewGroup(V1Prefix, mm...).Alias(APIPrefix)
+}
+
+// Alias serves the routes of the group under an additional prefix.
+func (g *Group) Alias(prefix string) *Group {
+	g.prefixes = append(g.prefixes, cleanPrefix(prefix))
+	return g
+}
+
+// PerRoute adds middlewares created per route from the path template of the route.
+// They run before the middlewares of the group.
+func (g *Group) PerRoute(ff ...PatternMiddlewareFunc) *Group {
+	g.perRoute = append(g.perRoute, ff...)
+	return g
+}
+
+// Get adds a GET route to the group.
+func (g *Group) Get(p string, pr sync.ProcessorFunc, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: http.MethodGet, processor: pr, trace: true, middlewares: mm})
+}
+
+// Post adds a POST route to the group.
+func (g *Group) Post(p string, pr sync.ProcessorFunc, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: http.MethodPost, processor: pr, trace: true, middlewares: mm})
+}
+
+// Put adds a PUT route to the group.
+func (g *Group) Put(p string, pr sync.ProcessorFunc, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: http.MethodPut, processor: pr, trace: true, middlewares: mm})
+}
+
+// Delete adds a DELETE route to the group.
+func (g *Group) Delete(p string, pr sync.ProcessorFunc, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: http.MethodDelete, processor: pr, trace: true, middlewares: mm})
+}
+
+// Auth adds a route with authentication to the group.
+func (g *Group) Auth(p, m string, pr sync.ProcessorFunc, a auth.Authenticator, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: m, processor: pr, trace: true, auth: a, middlewares: mm})
+}
+
+// Raw adds a route with a raw HTTP handler to the group.
+func (g *Group) Raw(p, m string, h http.HandlerFunc, mm ...patronhttp.MiddlewareFunc) *Group {
+	return g.add(spec{pattern: p, method: m, handler: h, trace: true, middlewares: mm})
+}
+
+func (g *Group) add(s spec) *Group {
+	g.specs = append(g.specs, s)
+	return g
+}
+
+// Routes returns the patron routes of the group, one per route and prefix.
+func (g *Group) Routes() []patronhttp.Route {
+	rr := make([]patronhttp.Route, 0, len(g.specs)*len(g.prefixes))
+	for _, prefix := range g.prefixes {
+		for _, s := range g.specs {
+			p := prefix + s.pattern
+			mm := make([]patronhttp.MiddlewareFunc, 0, len(g.perRoute)+len(g.middlewares)+len(s.middlewares))
+			for _, f := range g.perRoute {
+				mm = append(mm, f(p))
+			}
+			mm = append(mm, g.middlewares...)
+			mm = append(mm, s.middlewares...)
+			var r patronhttp.Route
+			if s.handler != nil {
+				r = patronhttp.NewAuthRouteRaw(p, s.method, s.handler, s.trace, s.auth, mm...)
+			} else {
+				r = patronhttp.NewRoute(p, s.method, s.processor, s.trace, s.auth, mm...)
+			}
+			rr = append(rr, withBeforeAuth(r, p, s.beforeAuth))
+		}
+	}
+	return rr
+}
+
+// withBeforeAuth inserts the middlewares created from the pattern after the tracing middleware of the route,
+// which patron puts first, so that they run before its authentication middleware.
+func withBeforeAuth(r patronhttp.Route, pattern string, ff []PatternMiddlewareFunc) patronhttp.Route {
+	if len(ff) == 0 {
+		return r
+	}
+	at := 0
+	if r.Trace {
+		at = 1
+	}
+	mm := make([]patronhttp.MiddlewareFunc, 0, len(r.Middlewares)+len(ff))
+	mm = append(mm, r.Middlewares[:at]...)
+	for _, f := range ff {
+		mm = append(mm, f(pattern))
+	}
+	r.Middlewares = append(mm, r.Middlewares[at:]...)
+	return r
+}
+
+func cleanPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
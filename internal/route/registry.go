@@ -0,0 +1,216 @@
+package route
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/sync"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/beatlabs/patron/sync/http/auth"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/middleware"
+)
+
+// NoTimeout disables the timeout of a route, e.g. for streaming responses.
+const NoTimeout time.Duration = -1
+
+// Spec declares a route of the API with everything needed to wire it.
+type Spec struct {
+	// Method of the route.
+	Method string
+	// Path of the route, relative to the prefix of the group.
+	Path string
+	// Processor of the route. Exactly one of Processor and Handler has to be set.
+	Processor sync.ProcessorFunc
+	// Handler of the route, for raw routes.
+	Handler http.HandlerFunc
+	// Auth requires the request to be authenticated by the authenticator of the registry.
+	Auth bool
+	// Timeout of the route. Defaults to middleware.DefaultTimeout, NoTimeout disables it.
+	Timeout time.Duration
+	// BodyLimit of the route in bytes. Defaults to middleware.DefaultBodyLimit for methods with a body.
+	BodyLimit int64
+	// RateClass is the rate limit class of the route, limited by the rate limiter of the registry.
+	RateClass string
+	// Cache serves the responses of the GET route from the response cache of the registry.
+	Cache bool
+	// Deprecation marks the route as deprecated, adding the deprecation headers to its responses.
+	Deprecation *Deprecation
+	// Middlewares of the route, running after the ones created from the spec.
+	Middlewares []patronhttp.MiddlewareFunc
+}
+
+// RateLimitFunc creates the rate limiting middleware of a rate limit class, or returns nil if the class does not exist.
+type RateLimitFunc func(class string) patronhttp.MiddlewareFunc
+
+// RegistryOptionFunc defines an option func for the registry.
+type RegistryOptionFunc func(*Registry) error
+
+// Authenticator option for setting the authenticator of the routes that require authentication.
+func Authenticator(a auth.Authenticator) RegistryOptionFunc {
+	return func(r *Registry) error {
+		if a == nil {
+			return errors.New("authenticator is nil")
+		}
+		r.auth = a
+		return nil
+	}
+}
+
+// RateLimit option for setting the func creating the rate limiting middlewares of the rate limit classes.
+func RateLimit(f RateLimitFunc) RegistryOptionFunc {
+	return func(r *Registry) error {
+		if f == nil {
+			return errors.New("rate limit func is nil")
+		}
+		r.rateLimit = f
+		return nil
+	}
+}
+
+// Cache option for setting the response cache of the routes that are cached.
+func Cache(c *middleware.ResponseCache) RegistryOptionFunc {
+	return func(r *Registry) error {
+		if c == nil {
+			return errors.New("response cache is nil")
+		}
+		r.cache = c
+		return nil
+	}
+}
+
+// Consumer option for setting the func identifying the consumers of the deprecated routes, e.g. by their
+// API key. It runs before the authentication of the route, so it can only rely on what the global
+// middlewares add to the context. It defaults to DefaultConsumer.
+func Consumer(f ConsumerFunc) RegistryOptionFunc {
+	return func(r *Registry) error {
+		if f == nil {
+			return errors.New("consumer func is nil")
+		}
+		r.consumer = f
+		return nil
+	}
+}
+
+// Registry holds the specs of the routes of the API, so that every route declares its wiring in one place.
+type Registry struct {
+	auth      auth.Authenticator
+	rateLimit RateLimitFunc
+	cache     *middleware.ResponseCache
+	consumer  ConsumerFunc
+	specs     []Spec
+	keys      map[string]struct{}
+}
+
+// NewRegistry creates a new route registry.
+func NewRegistry(oo ...RegistryOptionFunc) (*Registry, error) {
+	r := Registry{keys: map[string]struct{}{}, consumer: DefaultConsumer}
+	for _, o := range oo {
+		err := o(&r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// Add validates and adds route specs to the registry.
+func (r *Registry) Add(ss ...Spec) error {
+	for _, s := range ss {
+		if s.Method == "" || s.Path == "" {
+			return errors.New("route method and path are required")
+		}
+		key := s.Method + " " + s.Path
+		if (s.Processor == nil) == (s.Handler == nil) {
+			return errors.Errorf("route %s requires exactly one of processor and handler", key)
+		}
+		if _, ok := r.keys[key]; ok {
+			return errors.Errorf("route %s is already registered", key)
+		}
+		if s.Auth && r.auth == nil {
+			return errors.Errorf("route %s requires authentication but there is no authenticator", key)
+		}
+		if s.RateClass != "" {
+			if r.rateLimit == nil {
+				return errors.Errorf("route %s has a rate limit class but there is no rate limiter", key)
+			}
+			if r.rateLimit(s.RateClass) == nil {
+				return errors.Errorf("route %s has the unknown rate limit class %s", key, s.RateClass)
+			}
+		}
+		if s.Cache {
+			if s.Method != http.MethodGet {
+				return errors.Errorf("route %s is cached but is not a GET route", key)
+			}
+			if r.cache == nil {
+				return errors.Errorf("route %s is cached but there is no response cache", key)
+			}
+		}
+		if s.BodyLimit < 0 {
+			return errors.Errorf("route %s has a negative body limit", key)
+		}
+		if s.Timeout < 0 && s.Timeout != NoTimeout {
+			return errors.Errorf("route %s has a negative timeout", key)
+		}
+		if s.Deprecation != nil {
+			if err := s.Deprecation.validate(); err != nil {
+				return errors.Wrapf(err, "route %s", key)
+			}
+		}
+		r.keys[key] = struct{}{}
+		r.specs = append(r.specs, s)
+	}
+	return nil
+}
+
+// Apply adds the routes of the registry to the group, with the deprecation, rate limit, body limit,
+// cache and timeout middlewares of their specs. The deprecation runs before the authentication, so that
+// rejected requests are announced the deprecation and counted too.
+func (r *Registry) Apply(g *Group) *Group {
+	for _, s := range r.specs {
+		var pp []PatternMiddlewareFunc
+		if s.Deprecation != nil {
+			d, method := *s.Deprecation, s.Method
+			pp = append(pp, func(pattern string) patronhttp.MiddlewareFunc {
+				return deprecated(method+" "+pattern, d, r.consumer)
+			})
+		}
+		var mm []patronhttp.MiddlewareFunc
+		if s.RateClass != "" {
+			mm = append(mm, r.rateLimit(s.RateClass))
+		}
+		if limit := bodyLimit(s); limit > 0 {
+			mm = append(mm, middleware.NewBodyLimit(limit))
+		}
+		// the cache comes before the timeout, so that the responses refreshed in the background are timed out too.
+		if s.Cache {
+			mm = append(mm, r.cache.Middleware())
+		}
+		switch {
+		case s.Timeout == 0:
+			mm = append(mm, middleware.NewTimeout(middleware.DefaultTimeout))
+		case s.Timeout > 0:
+			mm = append(mm, middleware.NewTimeout(s.Timeout))
+		}
+		mm = append(mm, s.Middlewares...)
+
+		var a auth.Authenticator
+		if s.Auth {
+			a = r.auth
+		}
+		g.add(spec{pattern: s.Path, method: s.Method, processor: s.Processor, handler: s.Handler, trace: true, auth: a, beforeAuth: pp, middlewares: mm})
+	}
+	return g
+}
+
+func bodyLimit(s Spec) int64 {
+	if s.BodyLimit > 0 {
+		return s.BodyLimit
+	}
+	switch s.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return middleware.DefaultBodyLimit
+	default:
+		return 0
+	}
+}
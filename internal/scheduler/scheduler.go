@@ -0,0 +1,262 @@
+// Package scheduler provides a patron component that runs periodic background jobs,
+// such as ranking refreshes, purges and cleanups.
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "realworld",
+		Subsystem: "scheduler",
+		Name:      "job_runs_total",
+		Help:      "Number of job runs, by job and result (success, error, panic, skipped or follower).",
+	}, []string{"job", "result"})
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "realworld",
+		Subsystem: "scheduler",
+		Name:      "job_duration_seconds",
+		Help:      "Duration of job runs, by job.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"job"})
+	jobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "realworld",
+		Subsystem: "scheduler",
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run, by job.",
+	}, []string{"job"})
+)
+
+func init() {
+	prometheus.MustRegister(jobRuns, jobDuration, jobLastSuccess)
+}
+
+// Job definition of a periodic job.
+type Job struct {
+	// Name of the job, used in logs and metrics.
+	Name string
+	// Interval between two runs.
+	Interval time.Duration
+	// Jitter is the maximum random delay added to every run, to spread the load of replicas.
+	Jitter time.Duration
+	// Timeout of a single run. Defaults to the interval.
+	Timeout time.Duration
+	// PerInstance runs the job on every replica instead of only on the leader,
+	// e.g. for cleaning up state kept in memory.
+	PerInstance bool
+	// Run executes the job.
+	Run func(ctx context.Context) error
+}
+
+// Elector interface for deciding whether this instance is the leader of the replicas.
+// Only the leader runs the jobs, except the per-instance ones.
+type Elector interface {
+	Leader(ctx context.Context) (bool, error)
+}
+
+type alwaysLeader struct{}
+
+func (alwaysLeader) Leader(context.Context) (bool, error) {
+	return true, nil
+}
+
+// OptionFunc definition for configuring the scheduler in a functional way.
+type OptionFunc func(*Scheduler) error
+
+// Jobs option for adding jobs to the scheduler.
+func Jobs(jj ...Job) OptionFunc {
+	return func(s *Scheduler) error {
+		if len(jj) == 0 {
+			return errors.New("jobs are required")
+		}
+		for _, j := range jj {
+			if j.Name == "" {
+				return errors.New("job name is required")
+			}
+			if j.Interval <= 0 {
+				return errors.Errorf("job %s: interval must be positive", j.Name)
+			}
+			if j.Jitter < 0 {
+				return errors.Errorf("job %s: jitter must not be negative", j.Name)
+			}
+			if j.Run == nil {
+				return errors.Errorf("job %s: run func is required", j.Name)
+			}
+			if j.Timeout <= 0 {
+				j.Timeout = j.Interval
+			}
+			s.jobs = append(s.jobs, j)
+		}
+		return nil
+	}
+}
+
+// Leader option for setting the elector used when running multiple replicas.
+func Leader(e Elector) OptionFunc {
+	return func(s *Scheduler) error {
+		if e == nil {
+			return errors.New("elector is nil")
+		}
+		s.elector = e
+		return nil
+	}
+}
+
+// Scheduler runs jobs periodically. A job is never run concurrently with itself;
+// a tick is skipped if the previous run has not finished yet. It implements the patron Component interface.
+type Scheduler struct {
+	jobs    []Job
+	elector Elector
+	rndMu   sync.Mutex
+	rnd     *rand.Rand
+}
+
+// New creates a new scheduler.
+func New(oo ...OptionFunc) (*Scheduler, error) {
+	s := Scheduler{
+		elector: alwaysLeader{},
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, o := range oo {
+		err := o(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Run starts the jobs and blocks until the context is cancelled and all running jobs have returned.
+func (s *Scheduler) Run(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	wg.Add(len(s.jobs))
+	for _, j := range s.jobs {
+		go func(j Job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return nil
+}
+
+// Info returns information of the component.
+func (s *Scheduler) Info() map[string]interface{} {
+	jobs := make(map[string]interface{}, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs[j.Name] = map[string]interface{}{
+			"interval":    j.Interval.String(),
+			"jitter":      j.Jitter.String(),
+			"timeout":     j.Timeout.String(),
+			"perInstance": j.PerInstance,
+		}
+	}
+	return map[string]interface{}{"type": "scheduler", "jobs": jobs}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	tc := time.NewTicker(j.Interval)
+	defer tc.Stop()
+
+	running := make(chan struct{}, 1)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tc.C:
+		}
+
+		select {
+		case running <- struct{}{}:
+		default:
+			log.Warnf("job %s is still running, skipping run", j.Name)
+			jobRuns.WithLabelValues(j.Name, "skipped").Inc()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-running
+				wg.Done()
+			}()
+			s.execute(ctx, j)
+		}()
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, j Job) {
+	if d := s.jitter(j.Jitter); d > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d):
+		}
+	}
+
+	if !j.PerInstance {
+		leader, err := s.elector.Leader(ctx)
+		if err != nil {
+			log.Errorf("job %s: failed to elect leader: %v", j.Name, err)
+			jobRuns.WithLabelValues(j.Name, "error").Inc()
+			return
+		}
+		if !leader {
+			jobRuns.WithLabelValues(j.Name, "follower").Inc()
+			return
+		}
+	}
+
+	ctx, cnl := context.WithTimeout(ctx, j.Timeout)
+	defer cnl()
+
+	start := time.Now()
+	err := run(ctx, j)
+	jobDuration.WithLabelValues(j.Name).Observe(time.Since(start).Seconds())
+	if err == errPanic {
+		jobRuns.WithLabelValues(j.Name, "panic").Inc()
+		return
+	}
+	if err != nil {
+		log.Errorf("job %s failed: %v", j.Name, err)
+		jobRuns.WithLabelValues(j.Name, "error").Inc()
+		return
+	}
+	jobRuns.WithLabelValues(j.Name, "success").Inc()
+	jobLastSuccess.WithLabelValues(j.Name).SetToCurrentTime()
+}
+
+var errPanic = errors.New("job panicked")
+
+// run runs the job, recovering a panic so that it does not crash the service or stop the loop of the job.
+func run(ctx context.Context, j Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("job %s panicked: %v\n%s", j.Name, r, debug.Stack())
+			err = errPanic
+		}
+	}()
+	return j.Run(ctx)
+}
+
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.rndMu.Lock()
+	defer s.rndMu.Unlock()
+	return time.Duration(s.rnd.Int63n(int64(max)))
+}
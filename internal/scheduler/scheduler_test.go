@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+type follower struct{}
+
+func (follower) Leader(context.Context) (bool, error) {
+	return false, nil
+}
+
+func TestExecute(t *testing.T) {
+	tests := map[string]struct {
+		job  Job
+		want bool
+	}{
+		"leader job on follower":       {job: Job{Name: "purge"}},
+		"per-instance job on follower": {job: Job{Name: "prune", PerInstance: true}, want: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := New(Leader(follower{}))
+			if err != nil {
+				t.Fatalf("New() error: %v", err)
+			}
+			ran := false
+			tt.job.Timeout = time.Second
+			tt.job.Run = func(context.Context) error {
+				ran = true
+				return nil
+			}
+			s.execute(context.Background(), tt.job)
+			if ran != tt.want {
+				t.Errorf("job ran = %t, want %t", ran, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRecoversPanic(t *testing.T) {
+	j := Job{Name: "ranking", Run: func(context.Context) error {
+		panic("boom")
+	}}
+	if err := run(context.Background(), j); err != errPanic {
+		t.Errorf("run() = %v, want %v", err, errPanic)
+	}
+	j.Run = func(context.Context) error {
+		return errors.New("failed")
+	}
+	if err := run(context.Background(), j); err == nil || err == errPanic {
+		t.Errorf("run() = %v, want the error of the job", err)
+	}
+}
@@ -0,0 +1,144 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/textnorm"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// HeuristicOptionFunc defines an option func for the heuristic screener.
+type HeuristicOptionFunc func(*Heuristic) error
+
+// LinkDensity option for quarantining content with more than max links per 100 words.
+func LinkDensity(max float64) HeuristicOptionFunc {
+	return func(h *Heuristic) error {
+		if max <= 0 {
+			return errors.New("max link density must be positive")
+		}
+		h.maxLinkDensity = max
+		return nil
+	}
+}
+
+// BannedWords option for rejecting content containing any of the words, compared after normalization.
+func BannedWords(ww ...string) HeuristicOptionFunc {
+	return func(h *Heuristic) error {
+		for _, w := range ww {
+			for _, t := range textnorm.Terms(w, "") {
+				h.banned[t] = true
+			}
+		}
+		return nil
+	}
+}
+
+// Velocity option for quarantining the content of authors posting more than max items within the window.
+func Velocity(max int, window time.Duration) HeuristicOptionFunc {
+	return func(h *Heuristic) error {
+		if max <= 0 || window <= 0 {
+			return errors.New("max and window must be positive")
+		}
+		h.maxPosts = max
+		h.window = window
+		return nil
+	}
+}
+
+// Heuristic is a built-in screener based on the link density, banned words and posting velocity.
+type Heuristic struct {
+	sync.Mutex
+	maxLinkDensity float64
+	banned         map[string]bool
+	maxPosts       int
+	window         time.Duration
+	posts          map[string][]time.Time
+}
+
+// NewHeuristic creates a new heuristic screener.
+func NewHeuristic(oo ...HeuristicOptionFunc) (*Heuristic, error) {
+	h := Heuristic{
+		maxLinkDensity: 10,
+		banned:         make(map[string]bool),
+		maxPosts:       10,
+		window:         10 * time.Minute,
+		posts:          make(map[string][]time.Time),
+	}
+	for _, o := range oo {
+		err := o(&h)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &h, nil
+}
+
+// Screen screens the content. Rejected content does not count towards the velocity of the author.
+func (h *Heuristic) Screen(_ context.Context, c Content) (Verdict, error) {
+	var v Verdict
+	text := c.Title + "\n" + c.Body
+	terms := textnorm.Terms(text, c.Locale)
+
+	for _, t := range terms {
+		if h.banned[t] {
+			v.merge(Verdict{Action: Reject, Reasons: []string{c.Kind + " contains banned words"}})
+			return v, nil
+		}
+	}
+
+	if links := len(linkPattern.FindAllStringIndex(text, -1)); links > 0 {
+		density := float64(links) * 100 / float64(len(terms)+1)
+		if density > h.maxLinkDensity {
+			v.merge(Verdict{
+				Action:  Quarantine,
+				Reasons: []string{fmt.Sprintf("%s has too many links", c.Kind)},
+				Tags:    []string{"link-density"},
+			})
+		}
+	}
+
+	if h.record(c.AuthorID, c.Time) > h.maxPosts {
+		v.merge(Verdict{
+			Action:  Quarantine,
+			Reasons: []string{"author is posting too fast"},
+			Tags:    []string{"velocity"},
+		})
+	}
+	return v, nil
+}
+
+// record adds a post of the author and returns the number of posts within the window.
+func (h *Heuristic) record(author string, t time.Time) int {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	h.Lock()
+	defer h.Unlock()
+	pp := h.posts[author][:0]
+	for _, p := range h.posts[author] {
+		if t.Sub(p) < h.window {
+			pp = append(pp, p)
+		}
+	}
+	pp = append(pp, t)
+	h.posts[author] = pp
+	return len(pp)
+}
+
+// Prune removes the posts outside the window. It is meant to be run periodically.
+func (h *Heuristic) Prune() {
+	h.Lock()
+	defer h.Unlock()
+	now := time.Now()
+	for a, pp := range h.posts {
+		if len(pp) == 0 || now.Sub(pp[len(pp)-1]) >= h.window {
+			delete(h.posts, a)
+		}
+	}
+}
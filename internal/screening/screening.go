@@ -0,0 +1,153 @@
+// Package screening screens new articles and comments for spam and abuse before they are stored.
+package screening
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// Action decided for screened content, ordered from the most to the least permissive.
+type Action int
+
+const (
+	// Allow stores the content as is.
+	Allow Action = iota
+	// Tag stores the content with tags for later review.
+	Tag
+	// Quarantine stores the content hidden until a moderator approves it.
+	Quarantine
+	// Reject refuses the content with a validation error.
+	Reject
+)
+
+// String returns the name of the action.
+func (a Action) String() string {
+	switch a {
+	case Tag:
+		return "tag"
+	case Quarantine:
+		return "quarantine"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Kind of the screened content.
+const (
+	ArticleKind = "article"
+	CommentKind = "comment"
+)
+
+// Content to be screened.
+type Content struct {
+	Kind     string
+	AuthorID string
+	Title    string
+	Body     string
+	Locale   string
+	Time     time.Time
+}
+
+// Verdict of the screening.
+type Verdict struct {
+	Action  Action
+	Reasons []string
+	Tags    []string
+}
+
+// Error returns a 422 validation error with the reasons, if the content has been rejected.
+func (v Verdict) Error() error {
+	if v.Action != Reject {
+		return nil
+	}
+	return apierror.Validation(v.Reasons...)
+}
+
+func (v *Verdict) merge(o Verdict) {
+	if o.Action > v.Action {
+		v.Action = o.Action
+	}
+	v.Reasons = append(v.Reasons, o.Reasons...)
+	v.Tags = append(v.Tags, o.Tags...)
+}
+
+// Screener interface for screening content, e.g. with heuristics or an external service.
+type Screener interface {
+	Screen(ctx context.Context, c Content) (Verdict, error)
+}
+
+// Chain runs the screeners in order and returns the strictest verdict.
+type Chain []Screener
+
+// Screen runs the screeners, stopping at the first rejection.
+func (ch Chain) Screen(ctx context.Context, c Content) (Verdict, error) {
+	var v Verdict
+	for _, s := range ch {
+		sv, err := s.Screen(ctx, c)
+		if err != nil {
+			return Verdict{}, err
+		}
+		v.merge(sv)
+		if v.Action == Reject {
+			break
+		}
+	}
+	return v, nil
+}
+
+// Mode of the screening, configured per environment.
+type Mode string
+
+const (
+	// ModeOff disables the screening.
+	ModeOff Mode = "off"
+	// ModeTag only tags content, never quarantining or rejecting it, e.g. for evaluating rules in staging.
+	ModeTag Mode = "tag"
+	// ModeEnforce applies the verdicts.
+	ModeEnforce Mode = "enforce"
+)
+
+// ModeFromEnv returns the mode set by REALWORLD_SCREENING_MODE, defaulting to enforce.
+func ModeFromEnv() (Mode, error) {
+	m, ok := os.LookupEnv("REALWORLD_SCREENING_MODE")
+	if !ok {
+		return ModeEnforce, nil
+	}
+	switch Mode(strings.ToLower(m)) {
+	case ModeOff, ModeTag, ModeEnforce:
+		return Mode(strings.ToLower(m)), nil
+	}
+	return "", errors.Errorf("invalid screening mode %q", m)
+}
+
+type moded struct {
+	s    Screener
+	mode Mode
+}
+
+// WithMode wraps the screener so that its verdicts are applied according to the mode.
+func WithMode(s Screener, m Mode) Screener {
+	return moded{s: s, mode: m}
+}
+
+func (m moded) Screen(ctx context.Context, c Content) (Verdict, error) {
+	if m.mode == ModeOff {
+		return Verdict{}, nil
+	}
+	v, err := m.s.Screen(ctx, c)
+	if err != nil {
+		return Verdict{}, err
+	}
+	if m.mode == ModeTag && v.Action > Tag {
+		v.Tags = append(v.Tags, "would-"+v.Action.String())
+		v.Action = Tag
+	}
+	return v, nil
+}
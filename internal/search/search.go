@@ -0,0 +1,250 @@
+// Package search parses the query language of the article search, e.g.
+// tag:go author:jane "error handling" -draft before:2023-01-01, into a filter.
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/query"
+)
+
+const (
+	// MaxLength is the maximum length of a query in bytes.
+	MaxLength = 256
+	// MaxClauses is the maximum number of words, phrases and fields of a query.
+	MaxClauses = 20
+
+	dateFormat = "2006-01-02"
+)
+
+// Filter of the article search. All the conditions have to match.
+type Filter struct {
+	// Terms are the words and phrases the articles contain.
+	Terms []string
+	// ExcludeTerms are the words and phrases the articles do not contain.
+	ExcludeTerms []string
+	Tags         []string
+	ExcludeTags  []string
+	Author       string
+	Favorited    string
+	// Before excludes the articles created on or after the time.
+	Before time.Time
+	// After excludes the articles created before the time.
+	After time.Time
+}
+
+// ParseError is the error of a query that can't be parsed, at a 1-based character position.
+type ParseError struct {
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// errorBody is the RealWorld error envelope with the parse errors of the q param.
+type errorBody struct {
+	Errors struct {
+		Body []string     `json:"body"`
+		Q    []ParseError `json:"q"`
+	} `json:"errors"`
+}
+
+// Error returns the 422 error of a parse error, with the position of the error next to the message
+// of the RealWorld envelope, so that the frontends can highlight it.
+func Error(err error) *patronhttp.Error {
+	pe, ok := err.(*ParseError)
+	if !ok {
+		return apierror.Validation("q: " + err.Error())
+	}
+	var b errorBody
+	b.Errors.Body = []string{"q: " + pe.Error()}
+	b.Errors.Q = []ParseError{*pe}
+	return patronhttp.NewErrorWithCodeAndPayload(http.StatusUnprocessableEntity, b)
+}
+
+// clause of a query: a word or phrase, or a field with a value, which may be negated.
+type clause struct {
+	pos     int
+	negated bool
+	field   string
+	value   string
+}
+
+// Parse parses the query into a filter. Words and "quoted phrases" are search terms, field:value
+// pairs filter by tag, author, favorited, before and after (dates as YYYY-MM-DD), and a leading -
+// excludes a term or a tag.
+func Parse(q string) (Filter, error) {
+	var f Filter
+	if len(q) > MaxLength {
+		return f, &ParseError{Position: utf8.RuneCountInString(q[:MaxLength]) + 1, Message: fmt.Sprintf("query is longer than %d bytes", MaxLength)}
+	}
+	cc, err := lex(q)
+	if err != nil {
+		return f, err
+	}
+	if len(cc) > MaxClauses {
+		return f, &ParseError{Position: cc[MaxClauses].pos, Message: fmt.Sprintf("query has more than %d terms", MaxClauses)}
+	}
+
+	// positions of the date clauses, to report the later one if they contradict each other.
+	var beforePos, afterPos int
+	for _, c := range cc {
+		if c.value == "" {
+			return f, &ParseError{Position: c.pos, Message: "empty value"}
+		}
+		if c.negated && c.field != "" && c.field != "tag" {
+			return f, &ParseError{Position: c.pos, Message: fmt.Sprintf("%s can't be negated", c.field)}
+		}
+		switch c.field {
+		case "":
+			if c.negated {
+				f.ExcludeTerms = append(f.ExcludeTerms, c.value)
+			} else {
+				f.Terms = append(f.Terms, c.value)
+			}
+		case "tag":
+			if !query.Valid("tag", c.value) {
+				return f, &ParseError{Position: c.pos, Message: "tag is not valid"}
+			}
+			if c.negated {
+				f.ExcludeTags = append(f.ExcludeTags, c.value)
+			} else {
+				f.Tags = append(f.Tags, c.value)
+			}
+		case "author", "favorited":
+			if !query.Valid("username", c.value) {
+				return f, &ParseError{Position: c.pos, Message: c.field + " is not a valid username"}
+			}
+			if c.field == "author" {
+				err = set(&f.Author, c)
+			} else {
+				err = set(&f.Favorited, c)
+			}
+			if err != nil {
+				return f, err
+			}
+		case "before", "after":
+			t, err := time.Parse(dateFormat, c.value)
+			if err != nil {
+				return f, &ParseError{Position: c.pos, Message: c.field + " must be a date like 2006-01-02"}
+			}
+			if c.field == "before" {
+				err = setTime(&f.Before, t, c)
+				beforePos = c.pos
+			} else {
+				err = setTime(&f.After, t, c)
+				afterPos = c.pos
+			}
+			if err != nil {
+				return f, err
+			}
+		default:
+			return f, &ParseError{Position: c.pos, Message: fmt.Sprintf("unknown field %q", c.field)}
+		}
+	}
+	if !f.Before.IsZero() && !f.After.IsZero() && !f.After.Before(f.Before) {
+		pos := beforePos
+		if afterPos > pos {
+			pos = afterPos
+		}
+		return f, &ParseError{Position: pos, Message: "after must be earlier than before"}
+	}
+	return f, nil
+}
+
+func set(v *string, c clause) error {
+	if *v != "" && *v != c.value {
+		return &ParseError{Position: c.pos, Message: c.field + " is given more than once"}
+	}
+	*v = c.value
+	return nil
+}
+
+func setTime(v *time.Time, t time.Time, c clause) error {
+	if !v.IsZero() && !v.Equal(t) {
+		return &ParseError{Position: c.pos, Message: c.field + " is given more than once"}
+	}
+	*v = t
+	return nil
+}
+
+// lex splits the query into clauses, tracking the 1-based character position of each.
+func lex(q string) ([]clause, error) {
+	rr := []rune(q)
+	var cc []clause
+	i := 0
+	for i < len(rr) {
+		if unicode.IsSpace(rr[i]) {
+			i++
+			continue
+		}
+		c := clause{pos: i + 1}
+		if rr[i] == '-' {
+			c.negated = true
+			i++
+		}
+		if i < len(rr) && rr[i] == '"' {
+			v, n, err := quoted(rr, i)
+			if err != nil {
+				return nil, err
+			}
+			c.value, i = v, n
+			cc = append(cc, c)
+			continue
+		}
+
+		start := i
+		for i < len(rr) && !unicode.IsSpace(rr[i]) && rr[i] != ':' && rr[i] != '"' {
+			i++
+		}
+		word := string(rr[start:i])
+		if i < len(rr) && rr[i] == ':' {
+			if word == "" {
+				return nil, &ParseError{Position: i + 1, Message: "empty field"}
+			}
+			c.field = strings.ToLower(word)
+			i++
+			if i < len(rr) && rr[i] == '"' {
+				v, n, err := quoted(rr, i)
+				if err != nil {
+					return nil, err
+				}
+				c.value, i = v, n
+			} else {
+				start = i
+				for i < len(rr) && !unicode.IsSpace(rr[i]) {
+					i++
+				}
+				c.value = string(rr[start:i])
+			}
+		} else {
+			if i < len(rr) && rr[i] == '"' {
+				return nil, &ParseError{Position: i + 1, Message: "unexpected quote"}
+			}
+			c.value = word
+		}
+		cc = append(cc, c)
+	}
+	return cc, nil
+}
+
+// quoted returns the value of the quoted string starting at i, and the index after its closing quote.
+func quoted(rr []rune, i int) (string, int, error) {
+	end := i + 1
+	for end < len(rr) && rr[end] != '"' {
+		end++
+	}
+	if end == len(rr) {
+		return "", 0, &ParseError{Position: i + 1, Message: "unterminated quote"}
+	}
+	return strings.TrimSpace(string(rr[i+1 : end])), end + 1, nil
+}
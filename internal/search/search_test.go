@@ -0,0 +1,81 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(dateFormat, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		q    string
+		want Filter
+	}{
+		"empty":           {q: "", want: Filter{}},
+		"words":           {q: "error handling", want: Filter{Terms: []string{"error", "handling"}}},
+		"phrase":          {q: `"error handling" go`, want: Filter{Terms: []string{"error handling", "go"}}},
+		"excluded term":   {q: "-draft", want: Filter{ExcludeTerms: []string{"draft"}}},
+		"excluded phrase": {q: `-"work in progress"`, want: Filter{ExcludeTerms: []string{"work in progress"}}},
+		"tags":            {q: "tag:go -tag:java", want: Filter{Tags: []string{"go"}, ExcludeTags: []string{"java"}}},
+		"field case":      {q: "TAG:go", want: Filter{Tags: []string{"go"}}},
+		"author":          {q: "author:jane favorited:john", want: Filter{Author: "jane", Favorited: "john"}},
+		"same author":     {q: "author:jane author:jane", want: Filter{Author: "jane"}},
+		"dates":           {q: "after:2023-01-01 before:2023-02-01", want: Filter{After: date("2023-01-01"), Before: date("2023-02-01")}},
+		"same date":       {q: "before:2023-02-01 before:2023-02-01", want: Filter{Before: date("2023-02-01")}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tt.q)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.q, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := map[string]struct {
+		q    string
+		want ParseError
+	}{
+		"empty field":        {q: "go :go", want: ParseError{Position: 4, Message: "empty field"}},
+		"empty value":        {q: "tag:", want: ParseError{Position: 1, Message: "empty value"}},
+		"unknown field":      {q: "foo:bar", want: ParseError{Position: 1, Message: `unknown field "foo"`}},
+		"negated author":     {q: "-author:jane", want: ParseError{Position: 1, Message: "author can't be negated"}},
+		"invalid tag":        {q: "tag:a,b", want: ParseError{Position: 1, Message: "tag is not valid"}},
+		"unterminated quote": {q: `go "error`, want: ParseError{Position: 4, Message: "unterminated quote"}},
+		"unexpected quote":   {q: `go"`, want: ParseError{Position: 3, Message: "unexpected quote"}},
+		"invalid date":       {q: "before:yesterday", want: ParseError{Position: 1, Message: "before must be a date like 2006-01-02"}},
+		"two authors":        {q: "author:jane author:john", want: ParseError{Position: 13, Message: "author is given more than once"}},
+		"two befores":        {q: "before:2023-01-01 before:2023-02-01", want: ParseError{Position: 19, Message: "before is given more than once"}},
+		"two afters":         {q: "after:2023-01-01 after:2023-02-01", want: ParseError{Position: 18, Message: "after is given more than once"}},
+		"after not earlier":  {q: "before:2023-01-01 go after:2023-02-01", want: ParseError{Position: 22, Message: "after must be earlier than before"}},
+		"before not later":   {q: "after:2023-02-01 before:2023-01-01", want: ParseError{Position: 18, Message: "after must be earlier than before"}},
+		"too many clauses":   {q: strings.Repeat("go ", MaxClauses+1), want: ParseError{Position: 3*MaxClauses + 1, Message: "query has more than 20 terms"}},
+		"too long":           {q: strings.Repeat("a", MaxLength+1), want: ParseError{Position: MaxLength + 1, Message: "query is longer than 256 bytes"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tt.q)
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("Parse(%q) error = %v, want a parse error", tt.q, err)
+			}
+			if *pe != tt.want {
+				t.Errorf("Parse(%q) error = %+v, want %+v", tt.q, *pe, tt.want)
+			}
+		})
+	}
+}
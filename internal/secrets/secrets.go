@@ -0,0 +1,166 @@
+// Package secrets reads secrets such as the database password and the SMTP credentials
+// from a configurable provider, so that they do not have to be passed as plain env vars.
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/cache"
+)
+
+const (
+	// DBPassword is the name of the database password secret.
+	DBPassword = "db-password"
+	// SMTPPassword is the name of the SMTP password secret.
+	SMTPPassword = "smtp-password"
+)
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider interface for reading secrets by name.
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// EnvProvider reads secrets from env vars, e.g. db-password from REALWORLD_SECRET_DB_PASSWORD.
+// It is meant for local development.
+type EnvProvider struct{}
+
+// Get returns the value of the env var of the secret.
+func (EnvProvider) Get(_ context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv("REALWORLD_SECRET_" + strings.ToUpper(strings.Replace(name, "-", "_", -1)))
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// FileProvider reads secrets from the files of a directory, as mounted by Kubernetes or Docker secrets.
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a new file provider for the directory.
+func NewFileProvider(dir string) (*FileProvider, error) {
+	if dir == "" {
+		return nil, errors.New("secrets directory is required")
+	}
+	return &FileProvider{dir: dir}, nil
+}
+
+// Get returns the content of the file of the secret, without trailing new lines.
+func (p *FileProvider) Get(_ context.Context, name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return "", errors.Errorf("invalid secret name %q", name)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(p.dir, name))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read secret %s", name)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+type cached struct {
+	value   string
+	fetched time.Time
+}
+
+// Cache caches the secrets of a provider for a ttl. If refreshing a secret fails,
+// the cached value keeps being served, so that an outage of the provider does not affect the service.
+type Cache struct {
+	sync.Mutex
+	p       Provider
+	ttl     time.Duration
+	secrets map[string]cached
+	now     func() time.Time
+	// fetches deduplicates the concurrent fetches of a secret, which run without holding the lock.
+	fetches cache.Group
+}
+
+// NewCache creates a new cache for the provider. A zero ttl caches the secrets forever.
+func NewCache(p Provider, ttl time.Duration) (*Cache, error) {
+	if p == nil {
+		return nil, errors.New("provider is nil")
+	}
+	if ttl < 0 {
+		return nil, errors.New("ttl must not be negative")
+	}
+	return &Cache{p: p, ttl: ttl, secrets: make(map[string]cached), now: time.Now}, nil
+}
+
+// Get returns the secret from the cache, fetching it from the provider if it is missing or expired.
+func (c *Cache) Get(ctx context.Context, name string) (string, error) {
+	c.Lock()
+	s, ok := c.secrets[name]
+	c.Unlock()
+	if ok && (c.ttl == 0 || c.now().Sub(s.fetched) < c.ttl) {
+		return s.value, nil
+	}
+	v, _, err := c.fetches.Do(ctx, name, func(ctx context.Context) (interface{}, error) {
+		v, err := c.p.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		c.Lock()
+		c.secrets[name] = cached{value: v, fetched: c.now()}
+		c.Unlock()
+		return v, nil
+	})
+	if err != nil {
+		if ok && err != ErrNotFound {
+			log.FromContext(ctx).Warnf("failed to refresh secret %s, using cached value: %v", name, err)
+			return s.value, nil
+		}
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// NewProviderFromEnv creates the provider selected by REALWORLD_SECRETS_PROVIDER: env (default), file or vault.
+// The file provider reads from REALWORLD_SECRETS_DIR. The vault provider uses VAULT_ADDR and VAULT_TOKEN
+// and reads the KV secret at REALWORLD_VAULT_SECRET_PATH, e.g. secret/data/realworld.
+// The secrets are cached for REALWORLD_SECRETS_TTL (default 5m).
+func NewProviderFromEnv() (Provider, error) {
+	var (
+		p   Provider
+		err error
+	)
+	switch tp := os.Getenv("REALWORLD_SECRETS_PROVIDER"); tp {
+	case "", "env":
+		p = EnvProvider{}
+	case "file":
+		p, err = NewFileProvider(os.Getenv("REALWORLD_SECRETS_DIR"))
+	case "vault":
+		p, err = NewVaultProvider(os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN"), os.Getenv("REALWORLD_VAULT_SECRET_PATH"))
+	default:
+		return nil, errors.Errorf("unknown secrets provider %q", tp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := 5 * time.Minute
+	if v, ok := os.LookupEnv("REALWORLD_SECRETS_TTL"); ok {
+		ttl, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "env var for secrets ttl is not valid")
+		}
+	}
+	c, err := NewCache(p, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// VaultProvider reads secrets from the keys of a HashiCorp Vault KV version 2 secret.
+type VaultProvider struct {
+	url   string
+	token string
+	cl    *http.Client
+}
+
+// NewVaultProvider creates a new provider for the secret at the path of the Vault server,
+// e.g. secret/data/realworld.
+func NewVaultProvider(addr, token, path string) (*VaultProvider, error) {
+	if addr == "" || token == "" || path == "" {
+		return nil, errors.New("vault address, token and secret path are required")
+	}
+	return &VaultProvider{
+		url:   strings.TrimRight(addr, "/") + "/v1/" + strings.TrimLeft(path, "/"),
+		token: token,
+		cl:    &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+// Get returns the value of the key of the secret.
+func (p *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create vault request")
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	rsp, err := p.cl.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read secret from vault")
+	}
+	defer rsp.Body.Close()
+	switch rsp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrNotFound
+	default:
+		return "", errors.Errorf("vault returned status %d", rsp.StatusCode)
+	}
+
+	var vr vaultResponse
+	if err := json.NewDecoder(rsp.Body).Decode(&vr); err != nil {
+		return "", errors.Wrap(err, "failed to decode vault response")
+	}
+	v, ok := vr.Data.Data[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
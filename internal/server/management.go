@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ManagementRoutes returns the metrics and profiling routes, like the ones of the default patron component,
+// to be served by a private server.
+func ManagementRoutes() []patronhttp.Route {
+	rr := []patronhttp.Route{
+		patronhttp.NewRouteRaw("/metrics", http.MethodGet, promhttp.Handler().ServeHTTP, false),
+		patronhttp.NewRouteRaw("/debug/pprof/", http.MethodGet, pprof.Index, false),
+		patronhttp.NewRouteRaw("/debug/pprof/cmdline", http.MethodGet, pprof.Cmdline, false),
+		patronhttp.NewRouteRaw("/debug/pprof/profile", http.MethodGet, pprof.Profile, false),
+		patronhttp.NewRouteRaw("/debug/pprof/symbol", http.MethodGet, pprof.Symbol, false),
+		patronhttp.NewRouteRaw("/debug/pprof/trace", http.MethodGet, pprof.Trace, false),
+	}
+	for _, p := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		rr = append(rr, patronhttp.NewRouteRaw("/debug/pprof/"+p, http.MethodGet, pprof.Handler(p).ServeHTTP, false))
+	}
+	return rr
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// defaultPatronPort is the port of the default patron HTTP component if PATRON_HTTP_DEFAULT_PORT is not set.
+const defaultPatronPort = "50000"
+
+// Ports of the service with the management routes separated from the API.
+type Ports struct {
+	// API is the default address of the API server, on the public port. It is empty if REALWORLD_API_ADDR is set.
+	API string
+	// Management is the internal port of the default patron component.
+	Management string
+}
+
+// ManagementPortsFromEnv resolves the ports moving the management routes of the default patron component (health,
+// info, metrics and profiling) to the internal port of REALWORLD_MANAGEMENT_PORT, so that they are never exposed
+// through the public ingress. The API keeps the public port, being served on it by the server component
+// of NewFromEnv, unless REALWORLD_API_ADDR is set explicitly.
+// It only resolves the ports: patron reads the port of its default component only from PATRON_HTTP_DEFAULT_PORT,
+// so the caller has to set it to the management port before patron.New, and pass the API address to NewFromEnv.
+// It returns false if REALWORLD_MANAGEMENT_PORT is not set.
+func ManagementPortsFromEnv() (Ports, bool, error) {
+	port, ok := os.LookupEnv("REALWORLD_MANAGEMENT_PORT")
+	if !ok {
+		return Ports{}, false, nil
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return Ports{}, false, errors.Errorf("management port %q is not valid", port)
+	}
+	pp := Ports{Management: port}
+	if _, ok := os.LookupEnv("REALWORLD_API_ADDR"); !ok {
+		public, ok := os.LookupEnv("PATRON_HTTP_DEFAULT_PORT")
+		if !ok {
+			public = defaultPatronPort
+		}
+		if public == port {
+			return Ports{}, false, errors.New("management port must differ from the public port")
+		}
+		pp.API = ":" + public
+	}
+	return pp, true, nil
+}
@@ -0,0 +1,269 @@
+// Package server provides a patron component serving HTTP routes with TLS, HTTP/2, h2c, Unix sockets
+// and multiple listeners, which the default patron HTTP component does not support.
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	readTimeout     = 5 * time.Second
+	writeTimeout    = 10 * time.Second
+	idleTimeout     = 120 * time.Second
+	shutdownTimeout = 10 * time.Second
+
+	unixPrefix = "unix:"
+)
+
+// OptionFunc defines an option func for the server component.
+type OptionFunc func(*Component) error
+
+// TLS option for serving HTTPS with the certificate and key files. HTTP/2 is negotiated with ALPN.
+func TLS(certFile, keyFile string) OptionFunc {
+	return func(c *Component) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("cert and key files are required")
+		}
+		c.certFile = certFile
+		c.keyFile = keyFile
+		return nil
+	}
+}
+
+// H2C option for serving HTTP/2 without TLS, next to HTTP/1.1. It should only be used behind
+// a trusted load balancer that terminates TLS, since the traffic is not encrypted.
+func H2C() OptionFunc {
+	return func(c *Component) error {
+		c.h2c = true
+		return nil
+	}
+}
+
+// Timeouts option for setting the read and write timeouts of the server.
+func Timeouts(read, write time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if read <= 0 || write <= 0 {
+			return errors.New("timeouts must be positive")
+		}
+		c.readTimeout = read
+		c.writeTimeout = write
+		return nil
+	}
+}
+
+// Routes option for adding routes to the server.
+func Routes(rr []patronhttp.Route) OptionFunc {
+	return func(c *Component) error {
+		if len(rr) == 0 {
+			return errors.New("routes are empty")
+		}
+		c.routes = append(c.routes, rr...)
+		return nil
+	}
+}
+
+// Middlewares option for adding middlewares wrapping all routes of the server.
+func Middlewares(mm ...patronhttp.MiddlewareFunc) OptionFunc {
+	return func(c *Component) error {
+		if len(mm) == 0 {
+			return errors.New("middlewares are empty")
+		}
+		c.middlewares = append(c.middlewares, mm...)
+		return nil
+	}
+}
+
+// Component serving HTTP routes, like the default patron HTTP component but with TLS and protocol options.
+type Component struct {
+	addrs        []string
+	certFile     string
+	keyFile      string
+	h2c          bool
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	routes       []patronhttp.Route
+	middlewares  []patronhttp.MiddlewareFunc
+}
+
+// New creates a new server component listening on the addresses, which are either TCP addresses, e.g. ":8443",
+// or Unix socket paths prefixed with "unix:", e.g. "unix:/run/realworld/api.sock".
+func New(addrs []string, oo ...OptionFunc) (*Component, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("address is required")
+	}
+	for _, a := range addrs {
+		if a == "" || a == unixPrefix {
+			return nil, errors.Errorf("address %q is not valid", a)
+		}
+	}
+	c := Component{addrs: addrs, readTimeout: readTimeout, writeTimeout: writeTimeout}
+	for _, o := range oo {
+		err := o(&c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.h2c && c.certFile != "" {
+		return nil, errors.New("h2c can't be used with tls")
+	}
+	return &c, nil
+}
+
+// NewFromEnv creates a server component from REALWORLD_API_ADDR, a comma separated list of addresses, serving TLS with REALWORLD_TLS_CERT_FILE
+// and REALWORLD_TLS_KEY_FILE and h2c if REALWORLD_H2C is true. The default address is used if REALWORLD_API_ADDR is not set.
+// It returns false if neither is set, in which case the routes are served by the default patron component.
+func NewFromEnv(defaultAddr string, oo ...OptionFunc) (*Component, bool, error) {
+	addr, ok := os.LookupEnv("REALWORLD_API_ADDR")
+	if !ok {
+		addr = defaultAddr
+	}
+	if addr == "" {
+		return nil, false, nil
+	}
+	cert, key := os.Getenv("REALWORLD_TLS_CERT_FILE"), os.Getenv("REALWORLD_TLS_KEY_FILE")
+	if cert != "" || key != "" {
+		oo = append(oo, TLS(cert, key))
+	}
+	if os.Getenv("REALWORLD_H2C") == "true" {
+		oo = append(oo, H2C())
+	}
+	c, err := New(strings.Split(addr, ","), oo...)
+	if err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// NewAdminFromEnv creates the private admin server component from REALWORLD_ADMIN_ADDR, a comma separated
+// list of addresses that should only be reachable internally, e.g. "127.0.0.1:9090,unix:/run/realworld/admin.sock".
+// It returns false if REALWORLD_ADMIN_ADDR is not set, in which case the admin routes are served with the API.
+func NewAdminFromEnv(oo ...OptionFunc) (*Component, bool, error) {
+	addr, ok := os.LookupEnv("REALWORLD_ADMIN_ADDR")
+	if !ok {
+		return nil, false, nil
+	}
+	c, err := New(strings.Split(addr, ","), oo...)
+	if err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// Run starts the server on all addresses and shuts it down gracefully when the context is cancelled.
+func (c *Component) Run(ctx context.Context) error {
+	ll := make([]net.Listener, 0, len(c.addrs))
+	for _, a := range c.addrs {
+		l, err := listen(a)
+		if err != nil {
+			for _, l := range ll {
+				_ = l.Close()
+			}
+			return err
+		}
+		ll = append(ll, l)
+	}
+
+	srv := c.createHTTPServer()
+	chFail := make(chan error, len(ll))
+	for i, l := range ll {
+		go func(a string, l net.Listener) {
+			if c.certFile != "" {
+				log.Infof("HTTPS server listening on %s", a)
+				chFail <- srv.ServeTLS(l, c.certFile, c.keyFile)
+				return
+			}
+			log.Infof("HTTP server listening on %s (h2c: %t)", a, c.h2c)
+			chFail <- srv.Serve(l)
+		}(c.addrs[i], l)
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Infof("shutting down server on %s", strings.Join(c.addrs, ", "))
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(sctx)
+	case err := <-chFail:
+		_ = srv.Close()
+		return err
+	}
+}
+
+// listen listens on a TCP address or a Unix socket. A stale Unix socket left by a previous run is removed.
+func listen(addr string) (net.Listener, error) {
+	network, path := "tcp", addr
+	if strings.HasPrefix(addr, unixPrefix) {
+		network, path = "unix", strings.TrimPrefix(addr, unixPrefix)
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, errors.Wrapf(err, "failed to remove stale socket %s", path)
+			}
+		}
+	}
+	l, err := net.Listen(network, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", addr)
+	}
+	return l, nil
+}
+
+// Info returns information of the component.
+func (c *Component) Info() map[string]interface{} {
+	ii := map[string]interface{}{
+		"type":          "http",
+		"addrs":         strings.Join(c.addrs, ","),
+		"protocols":     c.protocols().String(),
+		"read-timeout":  c.readTimeout.String(),
+		"write-timeout": c.writeTimeout.String(),
+		"idle-timeout":  idleTimeout.String(),
+	}
+	if c.certFile != "" {
+		ii["type"] = "https"
+		ii["cert-file"] = c.certFile
+		ii["key-file"] = c.keyFile
+	}
+	return ii
+}
+
+func (c *Component) protocols() *http.Protocols {
+	var p http.Protocols
+	p.SetHTTP1(true)
+	switch {
+	case c.certFile != "":
+		p.SetHTTP2(true)
+	case c.h2c:
+		p.SetUnencryptedHTTP2(true)
+	}
+	return &p
+}
+
+func (c *Component) createHTTPServer() *http.Server {
+	router := httprouter.New()
+	for _, r := range c.routes {
+		if len(r.Middlewares) > 0 {
+			router.Handler(r.Method, r.Pattern, patronhttp.MiddlewareChain(r.Handler, r.Middlewares...))
+		} else {
+			router.HandlerFunc(r.Method, r.Pattern, r.Handler)
+		}
+	}
+	h := patronhttp.MiddlewareChain(router, patronhttp.NewRecoveryMiddleware())
+	h = patronhttp.MiddlewareChain(h, c.middlewares...)
+
+	return &http.Server{
+		ReadTimeout:  c.readTimeout,
+		WriteTimeout: c.writeTimeout,
+		IdleTimeout:  idleTimeout,
+		Handler:      h,
+		Protocols:    c.protocols(),
+	}
+}
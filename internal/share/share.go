@@ -0,0 +1,68 @@
+// Package share signs the tokens giving access to unlisted articles shared by link.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// Param is the query parameter carrying the share token, e.g. /api/articles/my-draft?share=<token>.
+const Param = "share"
+
+// Visibility of an article.
+type Visibility string
+
+const (
+	// Public articles are listed in feeds, lists, search and the sitemap.
+	Public Visibility = "public"
+	// Unlisted articles are only accessible with a valid share token, and are excluded from
+	// feeds, lists, search and the sitemap.
+	Unlisted Visibility = "unlisted"
+)
+
+// Listed returns true if articles of the visibility may appear in feeds, lists, search and the sitemap.
+func (v Visibility) Listed() bool {
+	return v != Unlisted
+}
+
+// Signer creates and verifies share tokens.
+//
+// A token is bound to the slug and to the share version of the article. The author revokes
+// all links shared so far by incrementing the version, which is stored with the article.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a new signer with the key, e.g. read from the secrets provider.
+func NewSigner(key []byte) (*Signer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("share key must be at least 32 bytes")
+	}
+	return &Signer{key: key}, nil
+}
+
+// Token returns the share token of the article.
+func (s *Signer) Token(slug string, version int) string {
+	return base64.RawURLEncoding.EncodeToString(s.mac(slug, version))
+}
+
+// Verify returns true if the token is valid for the article.
+func (s *Signer) Verify(slug string, version int, token string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(b, s.mac(slug, version))
+}
+
+func (s *Signer) mac(slug string, version int) []byte {
+	m := hmac.New(sha256.New, s.key)
+	_, _ = m.Write([]byte(slug))
+	_, _ = m.Write([]byte{0})
+	_, _ = m.Write([]byte(strconv.Itoa(version)))
+	return m.Sum(nil)
+}
@@ -0,0 +1,41 @@
+package signing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+type callerKey struct{}
+
+// Caller returns the key ID of the internal service that signed the request of the context.
+func Caller(ctx context.Context) (string, bool) {
+	k, ok := ctx.Value(callerKey{}).(string)
+	return k, ok
+}
+
+// Middleware creates a MiddlewareFunc verifying signed requests and adding their caller to the context.
+// Requests with a signature that is not valid are rejected with a 401, while unsigned requests are
+// served, so that they can authenticate otherwise.
+func Middleware(v *Verifier) patronhttp.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := v.Verify(r)
+			switch err {
+			case nil:
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, key)))
+			case ErrMissing:
+				next.ServeHTTP(w, r)
+			case ErrInvalid, ErrExpired, ErrReplayed:
+				log.FromContext(r.Context()).Warnf("rejected signed request %s %s: %v", r.Method, r.URL.Path, err)
+				apierror.Write(w, http.StatusUnauthorized, err.Error())
+			default:
+				log.FromContext(r.Context()).Errorf("failed to verify signed request: %v", err)
+				apierror.Write(w, http.StatusInternalServerError, "failed to verify request signature")
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package signing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NonceStore interface for remembering the used nonces.
+type NonceStore interface {
+	// Seen records the nonce for the ttl, returning true if it was already recorded.
+	Seen(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore, which only protects against replays to the same replica.
+type MemoryNonceStore struct {
+	sync.Mutex
+	nonces map[string]time.Time
+	pruned time.Time
+}
+
+// NewMemoryNonceStore creates a new in-memory nonce store.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Seen records the nonce for the ttl, returning true if it was already recorded.
+func (s *MemoryNonceStore) Seen(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	if now.Sub(s.pruned) > ttl {
+		for n, exp := range s.nonces {
+			if now.After(exp) {
+				delete(s.nonces, n)
+			}
+		}
+		s.pruned = now
+	}
+	if exp, ok := s.nonces[nonce]; ok && now.Before(exp) {
+		return true, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return false, nil
+}
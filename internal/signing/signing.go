@@ -0,0 +1,297 @@
+// Package signing signs and verifies the requests of trusted internal services, which authenticate
+// with an HMAC of the request and a shared key instead of a user JWT.
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/secrets"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Header is the header of the request signature, in the form k=<key id>,t=<unix time>,n=<nonce>,v1=<hex hmac>.
+	Header = "X-Request-Signature"
+
+	defaultMaxSkew = 5 * time.Minute
+	// maxBodyBytes bounds the body buffered before the signature is checked, since the middleware runs ahead
+	// of the body limits of the routes. It matches the default body limit of the routes.
+	maxBodyBytes = 64 << 10
+	// secretPrefix is the prefix of the names of the key secrets, e.g. signing-key-search for the key search.
+	secretPrefix = "signing-key-"
+)
+
+var (
+	// ErrMissing is returned if the request is not signed.
+	ErrMissing = errors.New("request is not signed")
+	// ErrInvalid is returned if the signature does not match the request, or its key is unknown.
+	ErrInvalid = errors.New("request signature is not valid")
+	// ErrExpired is returned if the signature time is outside of the allowed skew.
+	ErrExpired = errors.New("request signature has expired")
+	// ErrReplayed is returned if the nonce of the signature has been used before.
+	ErrReplayed = errors.New("request signature has been used before")
+)
+
+var verifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "signing",
+	Name:      "verifications_total",
+	Help:      "Number of request signature verifications, by result.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(verifications)
+}
+
+// Sign signs the request with the key at the time, setting the signature header.
+// The body is read and replaced, so that the request can still be sent.
+func Sign(req *http.Request, keyID, secret string, t time.Time) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.Wrap(err, "failed to generate nonce")
+	}
+	s := signature{key: keyID, ts: strconv.FormatInt(t.Unix(), 10), nonce: hex.EncodeToString(nonce)}
+	s.mac = mac(secret, canonical(req, body, s))
+	req.Header.Set(Header, s.String())
+	return nil
+}
+
+// OptionFunc defines an option func for the verifier.
+type OptionFunc func(*Verifier) error
+
+// MaxSkew option for setting the maximum difference between the signature time and the time of the verification.
+// The nonces are remembered for twice the skew, so that a replayed signature is either expired or known.
+func MaxSkew(d time.Duration) OptionFunc {
+	return func(v *Verifier) error {
+		if d <= 0 {
+			return errors.New("max skew must be positive")
+		}
+		v.maxSkew = d
+		return nil
+	}
+}
+
+// Nonces option for setting the store of the used nonces, which has to be shared by the replicas
+// for the replay protection to hold across them.
+func Nonces(s NonceStore) OptionFunc {
+	return func(v *Verifier) error {
+		if s == nil {
+			return errors.New("nonce store is nil")
+		}
+		v.nonces = s
+		return nil
+	}
+}
+
+// Verifier verifies signed requests. Keys are rotated by adding the new key next to the old one,
+// moving the callers to it, and removing the old key.
+// It implements the patron auth.Authenticator interface.
+type Verifier struct {
+	keys    map[string]string
+	maxSkew time.Duration
+	nonces  NonceStore
+	now     func() time.Time
+}
+
+// NewVerifier creates a new verifier for the secrets by key ID. By default the nonces are kept in memory.
+func NewVerifier(keys map[string]string, oo ...OptionFunc) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keys are required")
+	}
+	for id, secret := range keys {
+		if id == "" || strings.ContainsAny(id, ",=") {
+			return nil, errors.Errorf("key id %q is not valid", id)
+		}
+		if len(secret) < 32 {
+			return nil, errors.Errorf("secret of key %s must be at least 32 bytes", id)
+		}
+	}
+	v := Verifier{keys: keys, maxSkew: defaultMaxSkew, nonces: NewMemoryNonceStore(), now: time.Now}
+	for _, o := range oo {
+		err := o(&v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// NewVerifierFromEnv creates a verifier for the comma separated key IDs of REALWORLD_SIGNING_KEYS,
+// reading the secret of every key, e.g. signing-key-search for the key search, from the provider.
+// It returns false if the env var is not set, in which case request signing is disabled.
+func NewVerifierFromEnv(ctx context.Context, p secrets.Provider) (*Verifier, bool, error) {
+	ids, ok := os.LookupEnv("REALWORLD_SIGNING_KEYS")
+	if !ok {
+		return nil, false, nil
+	}
+	keys := make(map[string]string)
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		secret, err := p.Get(ctx, secretPrefix+id)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to get secret of signing key %s", id)
+		}
+		keys[id] = secret
+	}
+	v, err := NewVerifier(keys)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "env var for signing keys is not valid")
+	}
+	return v, true, nil
+}
+
+// Verify returns the key ID of a validly signed request. The body is read and replaced.
+func (v *Verifier) Verify(req *http.Request) (string, error) {
+	key, err := v.verify(req)
+	switch err {
+	case nil:
+		verifications.WithLabelValues("ok").Inc()
+	case ErrMissing:
+	case ErrInvalid:
+		verifications.WithLabelValues("invalid").Inc()
+	case ErrExpired:
+		verifications.WithLabelValues("expired").Inc()
+	case ErrReplayed:
+		verifications.WithLabelValues("replayed").Inc()
+	default:
+		verifications.WithLabelValues("error").Inc()
+	}
+	return key, err
+}
+
+func (v *Verifier) verify(req *http.Request) (string, error) {
+	h := req.Header.Get(Header)
+	if h == "" {
+		return "", ErrMissing
+	}
+	s, ok := parse(h)
+	if !ok {
+		return "", ErrInvalid
+	}
+	secret, ok := v.keys[s.key]
+	if !ok {
+		return "", ErrInvalid
+	}
+	sec, err := strconv.ParseInt(s.ts, 10, 64)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	if d := v.now().Sub(time.Unix(sec, 0)); d > v.maxSkew || d < -v.maxSkew {
+		return "", ErrExpired
+	}
+	// the body is read only for a known key and a current time, and only up to the limit.
+	if req.ContentLength > maxBodyBytes {
+		return "", ErrInvalid
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	if !hmac.Equal([]byte(s.mac), []byte(mac(secret, canonical(req, body, s)))) {
+		return "", ErrInvalid
+	}
+	// the nonce is recorded only for valid signatures, so that forged requests can't burn nonces.
+	seen, err := v.nonces.Seen(req.Context(), s.key+":"+s.nonce, 2*v.maxSkew)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check nonce")
+	}
+	if seen {
+		return "", ErrReplayed
+	}
+	return s.key, nil
+}
+
+// Authenticate returns true if the request is validly signed. Requests already verified by
+// the middleware are not verified again, since their nonce has been used.
+func (v *Verifier) Authenticate(req *http.Request) (bool, error) {
+	if _, ok := Caller(req.Context()); ok {
+		return true, nil
+	}
+	_, err := v.Verify(req)
+	switch err {
+	case nil:
+		return true, nil
+	case ErrMissing, ErrInvalid, ErrExpired, ErrReplayed:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+type signature struct {
+	key, ts, nonce, mac string
+}
+
+func (s signature) String() string {
+	return "k=" + s.key + ",t=" + s.ts + ",n=" + s.nonce + ",v1=" + s.mac
+}
+
+func parse(h string) (signature, bool) {
+	var s signature
+	for _, p := range strings.Split(h, ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) != 2 {
+			return s, false
+		}
+		switch kv[0] {
+		case "k":
+			s.key = kv[1]
+		case "t":
+			s.ts = kv[1]
+		case "n":
+			s.nonce = kv[1]
+		case "v1":
+			s.mac = kv[1]
+		}
+	}
+	return s, s.key != "" && s.ts != "" && s.nonce != "" && s.mac != ""
+}
+
+// canonical returns the signed string of the request: the method, the path with the query,
+// the hex SHA-256 of the body, the time and the nonce, separated by new lines.
+func canonical(req *http.Request, body []byte, s signature) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{req.Method, req.URL.RequestURI(), hex.EncodeToString(sum[:]), s.ts, s.nonce}, "\n")
+}
+
+func mac(secret, msg string) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	_, _ = m.Write([]byte(msg))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(req.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read body")
+	}
+	_ = req.Body.Close()
+	if len(b) > maxBodyBytes {
+		return nil, errors.New("body is too large to sign")
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
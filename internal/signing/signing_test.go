@@ -0,0 +1,271 @@
+package signing
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	secretA = "0123456789abcdef0123456789abcdef"
+	secretB = "fedcba9876543210fedcba9876543210"
+)
+
+func newVerifier(t *testing.T, keys map[string]string, now time.Time) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(keys)
+	if err != nil {
+		t.Fatalf("NewVerifier() error: %v", err)
+	}
+	v.now = func() time.Time { return now }
+	return v
+}
+
+func signedRequest(t *testing.T, body, keyID, secret string, at time.Time) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/internal/events?source=jobs", strings.NewReader(body))
+	if err := Sign(req, keyID, secret, at); err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+	return req
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+	req := signedRequest(t, `{"events":[]}`, "jobs", secretA, now)
+
+	key, err := v.Verify(req)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if key != "jobs" {
+		t.Errorf("Verify() key = %s, want jobs", key)
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(b) != `{"events":[]}` {
+		t.Errorf("body after Verify() = %s, want it unchanged", b)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := map[string]func(*http.Request){
+		"body":   func(r *http.Request) { r.Body = ioutil.NopCloser(strings.NewReader(`{"events":[{}]}`)) },
+		"method": func(r *http.Request) { r.Method = http.MethodPut },
+		"query":  func(r *http.Request) { r.URL.RawQuery = "source=other" },
+		"path":   func(r *http.Request) { r.URL.Path = "/internal/other" },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+			req := signedRequest(t, `{"events":[]}`, "jobs", secretA, now)
+			tamper(req)
+			if _, err := v.Verify(req); err != ErrInvalid {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestVerifyMissing(t *testing.T) {
+	v := newVerifier(t, map[string]string{"jobs": secretA}, time.Now())
+	req := httptest.NewRequest(http.MethodGet, "/internal/events", nil)
+	if _, err := v.Verify(req); err != ErrMissing {
+		t.Errorf("Verify() error = %v, want %v", err, ErrMissing)
+	}
+}
+
+func TestVerifySkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := map[string]struct {
+		signedAt time.Time
+		want     error
+	}{
+		"now":             {signedAt: now},
+		"within the past": {signedAt: now.Add(-defaultMaxSkew)},
+		"within future":   {signedAt: now.Add(defaultMaxSkew)},
+		"expired":         {signedAt: now.Add(-defaultMaxSkew - time.Second), want: ErrExpired},
+		"too far ahead":   {signedAt: now.Add(defaultMaxSkew + time.Second), want: ErrExpired},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+			req := signedRequest(t, "", "jobs", secretA, tt.signedAt)
+			if _, err := v.Verify(req); err != tt.want {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+	req := signedRequest(t, `{"events":[]}`, "jobs", secretA, now)
+	h := req.Header.Get(Header)
+
+	if _, err := v.Verify(req); err != nil {
+		t.Fatalf("first Verify() error: %v", err)
+	}
+	replay := httptest.NewRequest(http.MethodPost, "/internal/events?source=jobs", strings.NewReader(`{"events":[]}`))
+	replay.Header.Set(Header, h)
+	if _, err := v.Verify(replay); err != ErrReplayed {
+		t.Errorf("replayed Verify() error = %v, want %v", err, ErrReplayed)
+	}
+}
+
+func TestVerifyForgedDoesNotBurnNonce(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+	req := signedRequest(t, "", "jobs", secretA, now)
+	h := req.Header.Get(Header)
+
+	forged := httptest.NewRequest(http.MethodPost, "/internal/events?source=jobs", nil)
+	forged.Header.Set(Header, h[:strings.Index(h, "v1=")+3]+strings.Repeat("0", 64))
+	if _, err := v.Verify(forged); err != ErrInvalid {
+		t.Fatalf("forged Verify() error = %v, want %v", err, ErrInvalid)
+	}
+	if _, err := v.Verify(req); err != nil {
+		t.Errorf("Verify() after forged request error: %v", err)
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// the new key is added next to the old one, so that both are accepted while the callers move.
+	v := newVerifier(t, map[string]string{"jobs-1": secretA, "jobs-2": secretB}, now)
+	for _, k := range []struct{ id, secret string }{{"jobs-1", secretA}, {"jobs-2", secretB}} {
+		key, err := v.Verify(signedRequest(t, "", k.id, k.secret, now))
+		if err != nil {
+			t.Fatalf("Verify() with key %s error: %v", k.id, err)
+		}
+		if key != k.id {
+			t.Errorf("Verify() key = %s, want %s", key, k.id)
+		}
+	}
+	if _, err := v.Verify(signedRequest(t, "", "jobs-2", secretA, now)); err != ErrInvalid {
+		t.Errorf("Verify() with the secret of another key error = %v, want %v", err, ErrInvalid)
+	}
+
+	// once the old key is removed, its signatures are rejected.
+	v = newVerifier(t, map[string]string{"jobs-2": secretB}, now)
+	if _, err := v.Verify(signedRequest(t, "", "jobs-1", secretA, now)); err != ErrInvalid {
+		t.Errorf("Verify() with a removed key error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), maxBodyBytes+1)
+	req := httptest.NewRequest(http.MethodPost, "/internal/events", bytes.NewReader(body))
+	if err := Sign(req, "jobs", secretA, time.Now()); err == nil {
+		t.Error("Sign() with a body larger than the limit succeeded")
+	}
+
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+	req = signedRequest(t, "", "jobs", secretA, now)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if _, err := v.Verify(req); err != ErrInvalid {
+		t.Errorf("Verify() with a body larger than the limit error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestVerifyRejectsBeforeReadingBody(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := map[string]struct {
+		keyID    string
+		signedAt time.Time
+		want     error
+	}{
+		"unknown key": {keyID: "other", signedAt: now, want: ErrInvalid},
+		"expired":     {keyID: "jobs", signedAt: now.Add(-defaultMaxSkew - time.Second), want: ErrExpired},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+			req := signedRequest(t, "", tt.keyID, secretA, tt.signedAt)
+			body := &countingReader{r: strings.NewReader(`{"events":[]}`)}
+			req.Body = ioutil.NopCloser(body)
+			if _, err := v.Verify(req); err != tt.want {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if body.n > 0 {
+				t.Errorf("Verify() read %d bytes of the body, want none", body.n)
+			}
+		})
+	}
+
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+	req := signedRequest(t, "", "jobs", secretA, now)
+	body := &countingReader{r: bytes.NewReader(make([]byte, maxBodyBytes+1))}
+	req.Body = ioutil.NopCloser(body)
+	req.ContentLength = maxBodyBytes + 1
+	if _, err := v.Verify(req); err != ErrInvalid {
+		t.Errorf("Verify() with a declared body larger than the limit error = %v, want %v", err, ErrInvalid)
+	}
+	if body.n > 0 {
+		t.Errorf("Verify() read %d bytes of a body larger than the limit, want none", body.n)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+
+	ok, err := v.Authenticate(signedRequest(t, "", "jobs", secretA, now))
+	if err != nil || !ok {
+		t.Errorf("Authenticate() of a signed request = %t, %v, want true", ok, err)
+	}
+	ok, err = v.Authenticate(httptest.NewRequest(http.MethodGet, "/internal/events", nil))
+	if err != nil || ok {
+		t.Errorf("Authenticate() of an unsigned request = %t, %v, want false", ok, err)
+	}
+}
+
+func TestMiddlewareThenAuthenticate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := newVerifier(t, map[string]string{"jobs": secretA}, now)
+
+	var authenticated bool
+	h := Middleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the nonce is used by the middleware, so the authenticator has to rely on the caller of the context.
+		ok, err := v.Authenticate(r)
+		if err != nil {
+			t.Errorf("Authenticate() error: %v", err)
+		}
+		authenticated = ok
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, "", "jobs", secretA, now))
+	if !authenticated {
+		t.Error("request verified by the middleware was not authenticated")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest(t, "", "jobs", secretB, now))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status of an invalid signature = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
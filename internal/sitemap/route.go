@@ -0,0 +1,58 @@
+package sitemap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// Path is the path of the sitemap, or of the sitemap index if the sitemap has more than one page.
+	Path = "/sitemap.xml"
+
+	pagePrefix  = "/sitemaps/"
+	contentType = "application/xml; charset=utf-8"
+)
+
+// Routes creates the GET /sitemap.xml and /sitemaps/:page routes serving the cached sitemap.
+// They return 503 until the sitemap has been generated for the first time.
+func Routes(g *Generator) []patronhttp.Route {
+	index := func(w http.ResponseWriter, r *http.Request) {
+		b, ok := g.Index()
+		write(w, r, b, ok)
+	}
+	page := func(w http.ResponseWriter, r *http.Request) {
+		p := httprouter.ParamsFromContext(r.Context()).ByName("page")
+		n, err := strconv.Atoi(strings.TrimSuffix(p, ".xml"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		b, ok := g.Page(n)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		write(w, r, b, true)
+	}
+	return []patronhttp.Route{
+		patronhttp.NewRouteRaw(Path, http.MethodGet, index, true),
+		patronhttp.NewRouteRaw(pagePrefix+":page", http.MethodGet, page, true),
+	}
+}
+
+func write(w http.ResponseWriter, r *http.Request, b []byte, ok bool) {
+	if !ok {
+		http.Error(w, "sitemap is not generated yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if _, err := w.Write(b); err != nil {
+		log.FromContext(r.Context()).Errorf("failed to write sitemap: %v", err)
+	}
+}
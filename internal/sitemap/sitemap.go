@@ -0,0 +1,159 @@
+// Package sitemap generates the sitemap of the published articles and profiles for search engines.
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/scheduler"
+)
+
+// MaxURLs is the maximum number of URLs of a sitemap allowed by the protocol.
+// Larger sitemaps are split into pages listed by a sitemap index.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL of a page of the frontend, e.g. of an article or a profile.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// Source interface for getting the URLs of the published articles and the profiles.
+type Source interface {
+	URLs(ctx context.Context) ([]URL, error)
+}
+
+type urlset struct {
+	XMLName xml.Name   `xml:"urlset"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	Xmlns    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+// Generator generates the sitemap from the source and caches it until the next generation.
+type Generator struct {
+	sync.RWMutex
+	src     Source
+	baseURL string
+	index   []byte
+	pages   [][]byte
+}
+
+// NewGenerator creates a new generator. The baseURL is the public URL of the API,
+// used for the links of the sitemap index to its pages.
+func NewGenerator(src Source, baseURL string) (*Generator, error) {
+	if src == nil {
+		return nil, errors.New("source is nil")
+	}
+	if baseURL == "" {
+		return nil, errors.New("base url is required")
+	}
+	return &Generator{src: src, baseURL: baseURL}, nil
+}
+
+// Generate generates the sitemap from the source, replacing the cached one.
+func (g *Generator) Generate(ctx context.Context) error {
+	uu, err := g.src.URLs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sitemap urls")
+	}
+
+	var pages [][]byte
+	for start := 0; start < len(uu) || start == 0; start += MaxURLs {
+		end := start + MaxURLs
+		if end > len(uu) {
+			end = len(uu)
+		}
+		p, err := encodeURLSet(uu[start:end])
+		if err != nil {
+			return err
+		}
+		pages = append(pages, p)
+	}
+
+	index := pages[0]
+	if len(pages) > 1 {
+		index, err = g.encodeIndex(len(pages))
+		if err != nil {
+			return err
+		}
+	}
+
+	g.Lock()
+	defer g.Unlock()
+	g.index = index
+	g.pages = pages
+	log.Infof("sitemap generated with %d urls in %d pages", len(uu), len(pages))
+	return nil
+}
+
+// Index returns the cached sitemap, or the sitemap index if it has more than one page.
+// It returns false if the sitemap has not been generated yet.
+func (g *Generator) Index() ([]byte, bool) {
+	g.RLock()
+	defer g.RUnlock()
+	return g.index, g.index != nil
+}
+
+// Page returns the cached page of the sitemap, numbered from 1.
+func (g *Generator) Page(n int) ([]byte, bool) {
+	g.RLock()
+	defer g.RUnlock()
+	if n < 1 || n > len(g.pages) {
+		return nil, false
+	}
+	return g.pages[n-1], true
+}
+
+// Job returns a scheduler job regenerating the sitemap at the interval.
+func (g *Generator) Job(interval time.Duration) scheduler.Job {
+	return scheduler.Job{Name: "sitemap", Interval: interval, Jitter: interval / 10, Run: g.Generate}
+}
+
+func encodeURLSet(uu []URL) ([]byte, error) {
+	s := urlset{Xmlns: namespace, URLs: make([]urlEntry, 0, len(uu))}
+	for _, u := range uu {
+		e := urlEntry{Loc: u.Loc}
+		if !u.LastMod.IsZero() {
+			e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		s.URLs = append(s.URLs, e)
+	}
+	return encode(s)
+}
+
+func (g *Generator) encodeIndex(pages int) ([]byte, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	idx := sitemapIndex{Xmlns: namespace}
+	for i := 1; i <= pages; i++ {
+		idx.Sitemaps = append(idx.Sitemaps, urlEntry{Loc: g.baseURL + pagePrefix + strconv.Itoa(i) + ".xml", LastMod: now})
+	}
+	return encode(idx)
+}
+
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, errors.Wrap(err, "failed to encode sitemap")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package slug
+
+import (
+	"context"
+	"sync"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// maxRedirects limits the chain of old slugs followed, in case of a cycle in the history.
+const maxRedirects = 10
+
+// History interface for persisting the previous slugs of the articles, e.g. in a slug_history table,
+// so that links to an article keep working after its slug changes.
+type History interface {
+	// Record stores that the old slug now points to the new one.
+	Record(ctx context.Context, old, new string) error
+	// Lookup returns the slug the old slug points to.
+	Lookup(ctx context.Context, old string) (string, bool, error)
+	// Forget removes the history of a slug, when it is taken by another article.
+	Forget(ctx context.Context, slug string) error
+}
+
+// Resolve follows the history of a slug that does not exist anymore to the current slug.
+// It returns false if the slug has no history; the handler then responds with a 404,
+// otherwise with a 301 to the article of the current slug.
+func Resolve(ctx context.Context, h History, s string) (string, bool, error) {
+	cur, found := s, false
+	for i := 0; i < maxRedirects; i++ {
+		next, ok, err := h.Lookup(ctx, cur)
+		if err != nil {
+			return "", false, errors.Wrap(err, "failed to look up slug history")
+		}
+		if !ok {
+			return cur, found, nil
+		}
+		cur, found = next, true
+	}
+	return "", false, errors.Errorf("slug history of %q is too long", s)
+}
+
+// MemoryHistory is an in-memory implementation of the History.
+type MemoryHistory struct {
+	sync.RWMutex
+	slugs map[string]string
+}
+
+// NewMemoryHistory creates a new in-memory history.
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{slugs: make(map[string]string)}
+}
+
+// Record stores that the old slug now points to the new one.
+func (h *MemoryHistory) Record(_ context.Context, old, new string) error {
+	if old == new {
+		return errors.Errorf("slug %q can't point to itself", old)
+	}
+	h.Lock()
+	defer h.Unlock()
+	h.slugs[old] = new
+	return nil
+}
+
+// Lookup returns the slug the old slug points to.
+func (h *MemoryHistory) Lookup(_ context.Context, old string) (string, bool, error) {
+	h.RLock()
+	defer h.RUnlock()
+	s, ok := h.slugs[old]
+	return s, ok, nil
+}
+
+// Forget removes the history of a slug.
+func (h *MemoryHistory) Forget(_ context.Context, slug string) error {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.slugs, slug)
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package slug generates and validates the URL slugs of the articles.
+package slug
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/textnorm"
+)
+
+// MaxLength is the maximum length of a slug in characters, excluding the collision suffix.
+const MaxLength = 100
+
+// maxSuffix is the number of suffixes tried before giving up on a title.
+const maxSuffix = 100
+
+var (
+	valid    = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{Nd}]+(-[\p{Ll}\p{Lo}\p{Nd}]+)*$`)
+	invalid  = regexp.MustCompile(`[^\p{Ll}\p{Lo}\p{Nd}]+`)
+	reserved = map[string]bool{
+		"feed":    true,
+		"new":     true,
+		"edit":    true,
+		"rss":     true,
+		"import":  true,
+		"export":  true,
+		"search":  true,
+		"drafts":  true,
+		"admin":   true,
+		"api":     true,
+		"sitemap": true,
+	}
+)
+
+// ErrTaken is returned when a custom slug is used by another article.
+var ErrTaken = errors.New("slug is already taken")
+
+// Make generates a slug from the title, e.g. "Hello, World!" becomes "hello-world".
+func Make(title string) string {
+	return MakeLocale(title, "")
+}
+
+// MakeLocale generates a slug from the title in the locale, transliterating it to ASCII where possible,
+// e.g. "Über Go" becomes "uber-go". Letters of scripts without a transliteration, e.g. CJK, are kept.
+func MakeLocale(title, locale string) string {
+	s := invalid.ReplaceAllString(textnorm.Fold(title, locale), "-")
+	s = strings.Trim(s, "-")
+	if rr := []rune(s); len(rr) > MaxLength {
+		s = strings.TrimRight(string(rr[:MaxLength]), "-")
+	}
+	return s
+}
+
+// Validate checks a slug provided by the user.
+func Validate(s string) error {
+	switch {
+	case s == "":
+		return errors.New("slug can't be blank")
+	case utf8.RuneCountInString(s) > MaxLength:
+		return errors.Errorf("slug is too long (maximum is %d characters)", MaxLength)
+	case !valid.MatchString(s):
+		return errors.New("slug must contain only lowercase letters, digits and single dashes")
+	case reserved[s]:
+		return errors.Errorf("slug %q is reserved", s)
+	}
+	return nil
+}
+
+// ExistsFunc returns true if the slug is used by an article.
+type ExistsFunc func(ctx context.Context, slug string) (bool, error)
+
+// Unique returns the slug of the title, suffixed with -2, -3, ... if it is used by another article or reserved.
+// The uniqueness has still to be enforced by a unique index, since two articles may be created concurrently.
+func Unique(ctx context.Context, title string, exists ExistsFunc) (string, error) {
+	base := Make(title)
+	if base == "" {
+		return "", errors.New("title does not contain any letters or digits")
+	}
+	for i := 1; i <= maxSuffix; i++ {
+		s := base
+		if i > 1 {
+			s += "-" + strconv.Itoa(i)
+		}
+		if reserved[s] {
+			continue
+		}
+		ok, err := exists(ctx, s)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to check slug")
+		}
+		if !ok {
+			return s, nil
+		}
+	}
+	return "", errors.Errorf("no free slug found for %q", base)
+}
+
+// Custom validates a slug provided by the user and checks that it is not used by another article.
+func Custom(ctx context.Context, s string, exists ExistsFunc) error {
+	if err := Validate(s); err != nil {
+		return err
+	}
+	ok, err := exists(ctx, s)
+	if err != nil {
+		return errors.Wrap(err, "failed to check slug")
+	}
+	if ok {
+		return ErrTaken
+	}
+	return nil
+}
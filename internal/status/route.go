@@ -0,0 +1,34 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+	patronhttp "github.com/beatlabs/patron/sync/http"
+)
+
+// Path is the path of the status route, next to the patron /info route.
+const Path = "/status"
+
+// Route creates the GET /status route, returning the report of the registry with only whether each
+// dependency is up or down; the errors of the checks are logged.
+// The status code is 503 if any dependency is down, so that the route can also be probed.
+func Route(r *Registry) patronhttp.Route {
+	return patronhttp.NewRouteRaw(Path, http.MethodGet, func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Report(req.Context())
+		for _, d := range rep.Dependencies {
+			if d.Status == Down {
+				log.FromContext(req.Context()).Warnf("dependency %s is down: %s", d.Name, d.Error)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if rep.Status == Down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(rep); err != nil {
+			log.FromContext(req.Context()).Errorf("failed to write status: %v", err)
+		}
+	}, false)
+}
@@ -0,0 +1,26 @@
+package status
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// SQL returns a checker pinging the database. If versionQuery is not empty, e.g. "SELECT version()",
+// its single value is reported as the version of the database.
+func SQL(db *sql.DB, versionQuery string) CheckerFunc {
+	return func(ctx context.Context) (string, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return "", errors.Wrap(err, "failed to ping database")
+		}
+		if versionQuery == "" {
+			return "", nil
+		}
+		var v string
+		if err := db.QueryRowContext(ctx, versionQuery).Scan(&v); err != nil {
+			return "", errors.Wrap(err, "failed to query database version")
+		}
+		return v, nil
+	}
+}
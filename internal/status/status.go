@@ -0,0 +1,160 @@
+// Package status reports the status of the dependencies of the service and its build info.
+package status
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/buildinfo"
+)
+
+const (
+	// Up is the status of a dependency that responded to its check.
+	Up = "up"
+	// Down is the status of a dependency whose check failed.
+	Down = "down"
+
+	// DefaultTimeout of each check.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Checker checks a dependency, returning its version if known.
+type Checker interface {
+	Check(ctx context.Context) (string, error)
+}
+
+// CheckerFunc is an adapter allowing a func to be used as a Checker.
+type CheckerFunc func(ctx context.Context) (string, error)
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// Dependency is the result of the check of a dependency. The error is a detail for the logs and
+// the check command, and is not encoded.
+type Dependency struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latencyMs"`
+	Version   string  `json:"version,omitempty"`
+	Error     string  `json:"-"`
+}
+
+// Report of the status page.
+type Report struct {
+	Status       string         `json:"status"`
+	Build        buildinfo.Info `json:"build"`
+	Dependencies []Dependency   `json:"dependencies"`
+}
+
+// OptionFunc defines an option func for the registry.
+type OptionFunc func(*Registry) error
+
+// Timeout option for setting the timeout of each check.
+func Timeout(t time.Duration) OptionFunc {
+	return func(r *Registry) error {
+		if t <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		r.timeout = t
+		return nil
+	}
+}
+
+// Registry holds the checkers of the dependencies of the service.
+type Registry struct {
+	mu       sync.RWMutex
+	build    buildinfo.Info
+	timeout  time.Duration
+	checkers map[string]Checker
+}
+
+// NewRegistry creates a new registry reporting the build info, without the module dependencies.
+func NewRegistry(b buildinfo.Info, oo ...OptionFunc) (*Registry, error) {
+	b.Deps = nil
+	r := Registry{build: b, timeout: DefaultTimeout, checkers: map[string]Checker{}}
+	for _, o := range oo {
+		err := o(&r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// Add registers the checker of a dependency, e.g. db, cache, broker, blobstore or search.
+func (r *Registry) Add(name string, c Checker) error {
+	if name == "" {
+		return errors.New("dependency name is required")
+	}
+	if c == nil {
+		return errors.New("checker is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checkers[name]; ok {
+		return errors.Errorf("dependency %s is already registered", name)
+	}
+	r.checkers[name] = c
+	return nil
+}
+
+// Report checks all dependencies concurrently. The service is down if any of them is down.
+func (r *Registry) Report(ctx context.Context) Report {
+	r.mu.RLock()
+	cc := make(map[string]Checker, len(r.checkers))
+	for n, c := range r.checkers {
+		cc[n] = c
+	}
+	r.mu.RUnlock()
+
+	dd := make([]Dependency, 0, len(cc))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for n, c := range cc {
+		wg.Add(1)
+		go func(n string, c Checker) {
+			defer wg.Done()
+			d := r.check(ctx, n, c)
+			mu.Lock()
+			dd = append(dd, d)
+			mu.Unlock()
+		}(n, c)
+	}
+	wg.Wait()
+	sort.Slice(dd, func(i, j int) bool { return dd[i].Name < dd[j].Name })
+
+	rep := Report{Status: Up, Build: r.build, Dependencies: dd}
+	for _, d := range dd {
+		if d.Status == Down {
+			rep.Status = Down
+			break
+		}
+	}
+	return rep
+}
+
+func (r *Registry) check(ctx context.Context, name string, c Checker) Dependency {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	v, err := c.Check(ctx)
+	d := Dependency{
+		Name:      name,
+		Status:    Up,
+		LatencyMS: float64(time.Since(start)) / float64(time.Millisecond),
+		Version:   v,
+	}
+	if err != nil {
+		d.Status = Down
+		d.Error = err.Error()
+	}
+	return d
+}
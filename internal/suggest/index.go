@@ -0,0 +1,143 @@
+// Package suggest serves the autocompletion of tags and usernames by prefix from in-memory tries.
+package suggest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type node struct {
+	children map[rune]*node
+	// values are the indexed values ending at the node, by their original casing.
+	values map[string]bool
+}
+
+func newNode() *node {
+	return &node{children: make(map[rune]*node)}
+}
+
+// Index is a trie of values matched by a case-insensitive prefix.
+type Index struct {
+	sync.RWMutex
+	root *node
+	size int
+}
+
+// NewIndex creates a new index of the values.
+func NewIndex(vv ...string) *Index {
+	idx := Index{root: newNode()}
+	for _, v := range vv {
+		idx.add(v)
+	}
+	return &idx
+}
+
+// Add adds the value to the index.
+func (idx *Index) Add(v string) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.add(v)
+}
+
+func (idx *Index) add(v string) {
+	if v == "" {
+		return
+	}
+	n := idx.root
+	for _, r := range strings.ToLower(v) {
+		c, ok := n.children[r]
+		if !ok {
+			c = newNode()
+			n.children[r] = c
+		}
+		n = c
+	}
+	if n.values == nil {
+		n.values = make(map[string]bool)
+	}
+	if !n.values[v] {
+		n.values[v] = true
+		idx.size++
+	}
+}
+
+// Remove removes the value from the index, pruning the nodes left without values.
+func (idx *Index) Remove(v string) {
+	idx.Lock()
+	defer idx.Unlock()
+	path := []*node{idx.root}
+	rr := []rune(strings.ToLower(v))
+	n := idx.root
+	for _, r := range rr {
+		c, ok := n.children[r]
+		if !ok {
+			return
+		}
+		n = c
+		path = append(path, n)
+	}
+	if !n.values[v] {
+		return
+	}
+	delete(n.values, v)
+	idx.size--
+	for i := len(rr) - 1; i >= 0; i-- {
+		c := path[i+1]
+		if len(c.values) > 0 || len(c.children) > 0 {
+			return
+		}
+		delete(path[i].children, rr[i])
+	}
+}
+
+// Len returns the number of values of the index.
+func (idx *Index) Len() int {
+	idx.RLock()
+	defer idx.RUnlock()
+	return idx.size
+}
+
+// Prefix returns up to limit values starting with the prefix, ignoring case, shortest first and then
+// alphabetically, so that exact and close matches come first.
+func (idx *Index) Prefix(prefix string, limit int) []string {
+	idx.RLock()
+	defer idx.RUnlock()
+	n := idx.root
+	for _, r := range strings.ToLower(prefix) {
+		c, ok := n.children[r]
+		if !ok {
+			return nil
+		}
+		n = c
+	}
+
+	// breadth first, so that shorter values are collected first and the walk stops early.
+	var vv []string
+	level := []*node{n}
+	for len(level) > 0 && len(vv) < limit {
+		var next []*node
+		var found []string
+		for _, n := range level {
+			for v := range n.values {
+				found = append(found, v)
+			}
+			for _, c := range n.children {
+				next = append(next, c)
+			}
+		}
+		sort.Slice(found, func(i, j int) bool {
+			li, lj := strings.ToLower(found[i]), strings.ToLower(found[j])
+			if li == lj {
+				return found[i] < found[j]
+			}
+			return li < lj
+		})
+		vv = append(vv, found...)
+		level = next
+	}
+	if len(vv) > limit {
+		vv = vv[:limit]
+	}
+	return vv
+}
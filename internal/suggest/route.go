@@ -0,0 +1,57 @@
+package suggest
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/beatlabs/patron/sync"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/query"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/route"
+)
+
+const (
+	// TagsPath is the path of the tag suggestions, relative to the API group.
+	TagsPath = "/tags/suggest"
+	// ProfilesPath is the path of the username suggestions, relative to the API group. The profile route
+	// has to leave the suggest username to it, since the router does not allow a static segment next to a parameter.
+	ProfilesPath = "/profiles/suggest"
+
+	maxPrefix = 64
+)
+
+type suggestQuery struct {
+	Q     string `query:"q"`
+	Limit int    `query:"limit" default:"10" min:"1" max:"20"`
+}
+
+// Specs returns the specs of the GET /tags/suggest?q= and /profiles/suggest?q= routes of the API, returning
+// the tags and usernames starting with q. The requests are limited by the rate limit class, and the responses
+// are cached by the response cache of the registry.
+func Specs(tags, usernames *Index, rateClass string) []route.Spec {
+	return []route.Spec{
+		{Method: http.MethodGet, Path: TagsPath, Processor: processor(tags, "tags"), RateClass: rateClass, Cache: true},
+		{Method: http.MethodGet, Path: ProfilesPath, Processor: processor(usernames, "usernames"), RateClass: rateClass, Cache: true},
+	}
+}
+
+func processor(idx *Index, name string) sync.ProcessorFunc {
+	return func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		var q suggestQuery
+		if err := query.Bind(req.Fields, &q); err != nil {
+			return nil, err
+		}
+		if q.Q == "" {
+			return nil, apierror.Validation("q can't be blank")
+		}
+		if utf8.RuneCountInString(q.Q) > maxPrefix {
+			return nil, apierror.Validation("q is too long")
+		}
+		vv := idx.Prefix(q.Q, q.Limit)
+		if vv == nil {
+			vv = []string{}
+		}
+		return sync.NewResponse(map[string][]string{name: vv}), nil
+	}
+}
@@ -0,0 +1,176 @@
+// Package tenant resolves the tenant of a request when the service hosts multiple isolated Conduit instances.
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// Header is the header selecting the tenant, set by the API gateway in front of the service.
+// It is only honoured from the trusted proxies, since any other client could set it.
+const Header = "X-Tenant"
+
+// Tenant definition of an isolated instance of the service and the hosts it is served under.
+type Tenant struct {
+	ID    string
+	Hosts []string
+}
+
+// OptionFunc defines an option func for the registry.
+type OptionFunc func(*Registry) error
+
+// TrustedProxies option for honouring the tenant header of requests from the networks, e.g. "10.0.0.0/8",
+// which have to be the API gateways setting it. Single IPs are accepted as well.
+func TrustedProxies(cidrs ...string) OptionFunc {
+	return func(r *Registry) error {
+		for _, c := range cidrs {
+			c = strings.TrimSpace(c)
+			if !strings.Contains(c, "/") {
+				if strings.Contains(c, ":") {
+					c += "/128"
+				} else {
+					c += "/32"
+				}
+			}
+			_, n, err := net.ParseCIDR(c)
+			if err != nil {
+				return errors.Wrapf(err, "trusted proxy %s is not valid", c)
+			}
+			r.proxies = append(r.proxies, n)
+		}
+		return nil
+	}
+}
+
+// Registry resolves the tenants by ID or host.
+type Registry struct {
+	byID    map[string]Tenant
+	byHost  map[string]Tenant
+	proxies []*net.IPNet
+}
+
+// NewRegistry creates a new registry of the tenants.
+func NewRegistry(tt []Tenant, oo ...OptionFunc) (*Registry, error) {
+	if len(tt) == 0 {
+		return nil, errors.New("tenants are required")
+	}
+	r := Registry{byID: make(map[string]Tenant), byHost: make(map[string]Tenant)}
+	for _, t := range tt {
+		if t.ID == "" {
+			return nil, errors.New("tenant id is required")
+		}
+		if _, ok := r.byID[t.ID]; ok {
+			return nil, errors.Errorf("tenant %s is defined twice", t.ID)
+		}
+		r.byID[t.ID] = t
+		for _, h := range t.Hosts {
+			h = strings.ToLower(h)
+			if o, ok := r.byHost[h]; ok {
+				return nil, errors.Errorf("host %s is used by tenants %s and %s", h, o.ID, t.ID)
+			}
+			r.byHost[h] = t
+		}
+	}
+	for _, o := range oo {
+		err := o(&r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// NewRegistryFromEnv creates a registry from REALWORLD_TENANTS, e.g. "acme:acme.example.com|blog.acme.io,beta:beta.example.com",
+// honouring the tenant header from the comma separated networks of REALWORLD_TENANT_TRUSTED_PROXIES.
+// It returns false if the env var is not set, in which case the service is single-tenant.
+func NewRegistryFromEnv() (*Registry, bool, error) {
+	v, ok := os.LookupEnv("REALWORLD_TENANTS")
+	if !ok {
+		return nil, false, nil
+	}
+	var tt []Tenant
+	for _, def := range strings.Split(v, ",") {
+		parts := strings.SplitN(strings.TrimSpace(def), ":", 2)
+		t := Tenant{ID: parts[0]}
+		if len(parts) == 2 && parts[1] != "" {
+			t.Hosts = strings.Split(parts[1], "|")
+		}
+		tt = append(tt, t)
+	}
+	var oo []OptionFunc
+	if v := os.Getenv("REALWORLD_TENANT_TRUSTED_PROXIES"); v != "" {
+		oo = append(oo, TrustedProxies(strings.Split(v, ",")...))
+	}
+	r, err := NewRegistry(tt, oo...)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "env var for tenants is not valid")
+	}
+	return r, true, nil
+}
+
+// Resolve returns the tenant of the request, selected by the Host, or by the X-Tenant header
+// if the request comes from a trusted proxy.
+func (r *Registry) Resolve(req *http.Request) (Tenant, bool) {
+	if id := req.Header.Get(Header); id != "" && r.trusted(req.RemoteAddr) {
+		t, ok := r.byID[id]
+		return t, ok
+	}
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	t, ok := r.byHost[strings.ToLower(host)]
+	return t, ok
+}
+
+func (r *Registry) trusted(addr string) bool {
+	if len(r.proxies) == 0 {
+		return false
+	}
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		addr = h
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range r.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IDs returns the IDs of the tenants, e.g. for running migrations per tenant.
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.byID))
+	for id := range r.byID {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant.
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext returns the tenant of the context. Repositories and caches use its ID to scope their data.
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(Tenant)
+	return t, ok
+}
+
+// ID returns the ID of the tenant of the context, or an empty string in single-tenant deployments.
+func ID(ctx context.Context) string {
+	t, _ := FromContext(ctx)
+	return t.ID
+}
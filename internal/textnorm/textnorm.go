@@ -0,0 +1,53 @@
+// Package textnorm normalizes text for slugs and search, so that titles and queries in any language
+// compare equal regardless of case, accents and Unicode normalization form.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fold lowercases the text for the locale, e.g. tr, and transliterates it to ASCII where possible,
+// e.g. "Über Go" becomes "uber go". Combining marks are removed, so decomposed (NFD) input gives the same
+// result as composed (NFC) input. Letters without a transliteration, e.g. CJK, are kept.
+func Fold(s, locale string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		r = toLower(r, locale)
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if t, ok := translit[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Terms folds the text and splits it into the words used for search indexing and matching.
+func Terms(s, locale string) []string {
+	return strings.FieldsFunc(Fold(s, locale), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func toLower(r rune, locale string) rune {
+	switch language(locale) {
+	case "tr", "az":
+		// Turkish and Azeri lowercase I to dotless ı and İ to i.
+		return unicode.TurkishCase.ToLower(r)
+	default:
+		return unicode.ToLower(r)
+	}
+}
+
+// language returns the language of a locale, e.g. tr for tr-TR.
+func language(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// RecoveryCodes is the number of recovery codes generated per enrollment.
+const RecoveryCodes = 10
+
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// NewRecoveryCodes generates n single-use recovery codes. It returns the plain codes,
+// which have to be shown to the user once, and their hashes, which have to be stored.
+func NewRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b, err := randomString(10)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to generate recovery code")
+		}
+		c := b[:5] + "-" + b[5:]
+		codes = append(codes, c)
+		hashes = append(hashes, HashRecoveryCode(c))
+	}
+	return codes, hashes, nil
+}
+
+// randomString returns n characters of the recovery alphabet drawn uniformly. Random bytes above the largest
+// multiple of the alphabet length are rejected, since taking them modulo the length would favour the first characters.
+func randomString(n int) (string, error) {
+	max := 256 - 256%len(recoveryAlphabet)
+	s := make([]byte, 0, n)
+	b := make([]byte, n)
+	for len(s) < n {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, c := range b {
+			if int(c) < max && len(s) < n {
+				s = append(s, recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return string(s), nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage, ignoring case and separators.
+func HashRecoveryCode(code string) string {
+	c := strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	sum := sha256.Sum256([]byte(c))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode checks the code against the stored hashes. If it matches,
+// it returns the hashes without the used one, which have to be stored in place of the old ones.
+func UseRecoveryCode(hashes []string, code string) ([]string, bool) {
+	h := HashRecoveryCode(code)
+	for i, s := range hashes {
+		if subtle.ConstantTimeCompare([]byte(s), []byte(h)) == 1 {
+			left := make([]string, 0, len(hashes)-1)
+			left = append(left, hashes[:i]...)
+			return append(left, hashes[i+1:]...), true
+		}
+	}
+	return hashes, false
+}
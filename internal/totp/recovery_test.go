@@ -0,0 +1,48 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(RecoveryCodes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != RecoveryCodes || len(hashes) != RecoveryCodes {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(hashes), RecoveryCodes)
+	}
+	for i, c := range codes {
+		if len(c) != 11 || c[5] != '-' {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", c)
+		}
+		for _, r := range strings.Replace(c, "-", "", 1) {
+			if !strings.ContainsRune(recoveryAlphabet, r) {
+				t.Errorf("code %q contains %q, which is not in the alphabet", c, r)
+			}
+		}
+		if hashes[i] != HashRecoveryCode(c) {
+			t.Errorf("hash of code %q does not match", c)
+		}
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	left, ok := UseRecoveryCode(hashes, " "+strings.ToUpper(codes[1])+" ")
+	if !ok {
+		t.Fatal("expected code to be accepted ignoring case and spaces")
+	}
+	if len(left) != 2 || left[0] != hashes[0] || left[1] != hashes[2] {
+		t.Errorf("unexpected hashes left: %v", left)
+	}
+
+	if _, ok := UseRecoveryCode(left, codes[1]); ok {
+		t.Error("expected used code to be rejected")
+	}
+}
@@ -0,0 +1,106 @@
+// Package totp implements time-based one-time passwords (RFC 6238) for two-factor authentication.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const (
+	// Digits is the number of digits of the codes.
+	Digits = 6
+	// Period is the time step of the codes.
+	Period = 30 * time.Second
+	// Skew is the number of time steps before and after the current one in which codes are accepted,
+	// to tolerate clock drift between the service and the device.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a new random secret, base32 encoded as expected by authenticator apps.
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate secret")
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// ProvisioningURI returns the otpauth URI of the secret, to be rendered as a QR code by the client.
+func ProvisioningURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period/time.Second)))
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// Code returns the code of the secret at the time.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, step(t)), nil
+}
+
+// Verify checks the code for the secret at the time, within the allowed skew. last is the time step of the
+// last code accepted for the user, or 0; codes of that step or earlier are rejected, so that an observed code
+// can't be replayed. If the code is valid, it returns its time step, which has to be stored in place of last.
+func Verify(secret, code string, t time.Time, last uint64) (uint64, bool, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	now := step(t)
+	var matched uint64
+	for i := -Skew; i <= Skew; i++ {
+		s := now + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, s)), []byte(code)) == 1 && s > last {
+			matched = s
+		}
+	}
+	return matched, matched != 0, nil
+}
+
+func decode(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid secret")
+	}
+	return key, nil
+}
+
+func step(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(Period/time.Second)
+}
+
+// hotp returns the HOTP code (RFC 4226) of the key at the counter.
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, see RFC 4226 section 5.3.
+	off := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, v%1000000)
+}
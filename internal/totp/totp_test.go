@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoding of the SHA-1 seed "12345678901234567890" of the RFC 6238 test vectors.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// the RFC 6238 appendix B vectors are 8 digits, so the codes are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d) error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", time.Unix(59, 0)); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	tests := map[string]struct {
+		code     string
+		at       time.Time
+		last     uint64
+		wantStep uint64
+		wantOK   bool
+	}{
+		"current step":          {code: "050471", at: now, wantStep: 37037037, wantOK: true},
+		"previous step in skew": {code: "050471", at: now.Add(Period), wantStep: 37037037, wantOK: true},
+		"next step in skew":     {code: "050471", at: now.Add(-Period), wantStep: 37037037, wantOK: true},
+		"outside of skew":       {code: "050471", at: now.Add(2 * Period)},
+		"wrong code":            {code: "123456", at: now},
+		"replayed step":         {code: "050471", at: now, last: 37037037},
+		"earlier than last":     {code: "050471", at: now, last: 37037038},
+		"later than last":       {code: "050471", at: now, last: 37037036, wantStep: 37037037, wantOK: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			step, ok, err := Verify(rfcSecret, tt.code, tt.at, tt.last)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Verify() = %d, %t, want %d, %t", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	got := ProvisioningURI("Conduit", "jane@example.com", rfcSecret)
+	if !strings.HasPrefix(got, "otpauth://totp/Conduit:jane@example.com?") {
+		t.Errorf("unexpected label in %s", got)
+	}
+	for _, p := range []string{"secret=" + rfcSecret, "issuer=Conduit", "digits=6", "period=30"} {
+		if !strings.Contains(got, p) {
+			t.Errorf("%s does not contain %s", got, p)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/info"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/config"
+	"github.com/uber/jaeger-client-go/rpcmetrics"
+	"github.com/uber/jaeger-lib/metrics/prometheus"
+)
+
+const (
+	// BackendJaeger reports spans to a Jaeger agent.
+	BackendJaeger = "jaeger"
+	// BackendDisabled disables tracing.
+	BackendDisabled = "disabled"
+	// BackendOTLP would export spans to an OTLP endpoint. It is not supported, since patron traces with
+	// OpenTracing and no OpenTelemetry exporter is vendored; it is rejected instead of falling back to Jaeger.
+	BackendOTLP = "otlp"
+
+	serviceVersionTag = "service.version"
+	revisionTag       = "vcs.revision"
+	environmentTag    = "deployment.environment"
+)
+
+// Setup replaces the tracer set up by patron with one configured by env vars, closing patron's tracer.
+// It has to be called after patron.New, and the returned closer has to be closed on shutdown.
+//
+// REALWORLD_TRACING_BACKEND selects the backend (jaeger or disabled; default jaeger; otlp is out of scope) and
+// REALWORLD_ENVIRONMENT sets the deployment.environment tag of the spans (default development).
+// The Jaeger backend uses the same PATRON_JAEGER_* env vars as patron.
+// The spans are tagged with the version and the VCS revision of the build.
+func Setup(name, version, revision string) (io.Closer, error) {
+	backend, err := BackendFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	env, ok := os.LookupEnv("REALWORLD_ENVIRONMENT")
+	if !ok {
+		env = "development"
+	}
+	info.UpsertConfig("tracing-backend", backend)
+	info.UpsertConfig("environment", env)
+
+	// patron closes its tracer again when the service stops, which the reporter ignores.
+	if err := trace.Close(); err != nil {
+		log.Errorf("failed to close the tracer of patron: %v", err)
+	}
+	if backend == BackendDisabled {
+		log.Info("tracing is disabled")
+		opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+		return ioutil.NopCloser(nil), nil
+	}
+	return setupJaeger(name, version, revision, env)
+}
+
+// BackendFromEnv returns the tracing backend of REALWORLD_TRACING_BACKEND,
+// failing if it is unknown.
+func BackendFromEnv() (string, error) {
+	backend, ok := os.LookupEnv("REALWORLD_TRACING_BACKEND")
+	if !ok {
+		return BackendJaeger, nil
+	}
+	switch backend {
+	case BackendJaeger, BackendDisabled:
+		return backend, nil
+	case BackendOTLP:
+		return "", errors.Errorf("tracing backend %q is not supported, use %q or %q", backend, BackendJaeger, BackendDisabled)
+	default:
+		return "", errors.Errorf("unknown tracing backend %q", backend)
+	}
+}
+
+func setupJaeger(name, version, revision, env string) (io.Closer, error) {
+	agent, ok := os.LookupEnv("PATRON_JAEGER_AGENT")
+	if !ok {
+		agent = "0.0.0.0:6831"
+	}
+	tp, ok := os.LookupEnv("PATRON_JAEGER_SAMPLER_TYPE")
+	if !ok {
+		tp = jaeger.SamplerTypeProbabilistic
+	}
+	prm := 0.0
+	if v, ok := os.LookupEnv("PATRON_JAEGER_SAMPLER_PARAM"); ok {
+		var err error
+		prm, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "env var for jaeger sampler param is not valid")
+		}
+	}
+
+	cfg := config.Configuration{
+		ServiceName: name,
+		Sampler: &config.SamplerConfig{
+			Type:  tp,
+			Param: prm,
+		},
+		Reporter: &config.ReporterConfig{
+			BufferFlushInterval: time.Second,
+			LocalAgentHostPort:  agent,
+		},
+		Tags: []opentracing.Tag{
+			{Key: serviceVersionTag, Value: version},
+			{Key: revisionTag, Value: revision},
+			{Key: environmentTag, Value: env},
+		},
+	}
+	// the RPC metrics of the spans are kept, under the namespace of the service since patron's tracer
+	// has registered its own under sync.
+	obs := rpcmetrics.NewObserver(prometheus.New().Namespace("realworld", nil), rpcmetrics.DefaultNameNormalizer)
+	tr, cls, err := cfg.NewTracer(config.Observer(obs))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create jaeger tracer")
+	}
+	opentracing.SetGlobalTracer(tr)
+	log.Infof("tracing to jaeger agent %s with sampler %s(%v)", agent, tp, prm)
+	return cls, nil
+}
@@ -0,0 +1,51 @@
+// Package tracing contains helpers on top of the patron tracing setup.
+package tracing
+
+import (
+	"context"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+)
+
+// TraceID returns the ID of the trace of the span in the context, or an empty string if there is none.
+func TraceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sp := opentracing.SpanFromContext(ctx)
+	if sp == nil {
+		return ""
+	}
+	if sc, ok := sp.Context().(jaeger.SpanContext); ok {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+const (
+	// ArticleSlugTag is the span tag of the slug of the article handled.
+	ArticleSlugTag = "article.slug"
+	// AuthorTag is the span tag of the username of the author of the content handled.
+	AuthorTag = "article.author"
+	// UserTag is the span tag of the ID of the authenticated user.
+	UserTag = "user.id"
+)
+
+// SetTag attaches a business attribute to the span in the context, if any.
+func SetTag(ctx context.Context, key string, value interface{}) {
+	if sp := opentracing.SpanFromContext(ctx); sp != nil {
+		sp.SetTag(key, value)
+	}
+}
+
+// SetTags attaches business attributes to the span in the context, if any.
+func SetTags(ctx context.Context, tags map[string]interface{}) {
+	sp := opentracing.SpanFromContext(ctx)
+	if sp == nil {
+		return
+	}
+	for k, v := range tags {
+		sp.SetTag(k, v)
+	}
+}
@@ -0,0 +1,36 @@
+package unfurl
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/sync"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+)
+
+// Path is the path of the unfurl route, relative to the API prefix.
+const Path = "/unfurl"
+
+type previewResponse struct {
+	Preview Preview `json:"preview"`
+}
+
+// Processor returns the processor of GET /api/unfurl?url=, which returns the preview of the URL.
+func Processor(u *Unfurler) sync.ProcessorFunc {
+	return func(ctx context.Context, req *sync.Request) (*sync.Response, error) {
+		raw := req.Fields["url"]
+		if raw == "" {
+			return nil, apierror.Validation("url can't be blank")
+		}
+		p, err := u.Unfurl(ctx, raw)
+		if err == ErrInvalidURL {
+			return nil, apierror.Validation(err.Error())
+		}
+		if err != nil {
+			log.FromContext(ctx).Debugf("failed to unfurl %s: %v", raw, err)
+			return nil, apierror.New(http.StatusBadGateway, "failed to fetch a preview of the url")
+		}
+		return sync.NewResponse(previewResponse{Preview: p}), nil
+	}
+}
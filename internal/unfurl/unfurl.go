@@ -0,0 +1,241 @@
+// Package unfurl fetches the OpenGraph metadata of the URLs embedded in articles, so that the frontends
+// can render link previews.
+package unfurl
+
+import (
+	"context"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/cache"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/netguard"
+)
+
+const (
+	defaultTimeout    = 5 * time.Second
+	defaultMaxBytes   = 512 << 10
+	defaultTTL        = 24 * time.Hour
+	defaultMaxEntries = 10000
+	errorTTL          = time.Minute
+	maxRedirects      = 3
+)
+
+var (
+	metaTag  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attr     = regexp.MustCompile(`(?is)(property|name|content)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+	titleTag = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// ErrInvalidURL is returned for URLs that are not absolute http(s) URLs.
+var ErrInvalidURL = errors.New("url must be an absolute http or https url")
+
+// Preview metadata of a URL.
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"siteName,omitempty"`
+}
+
+type cacheEntry struct {
+	preview Preview
+	err     error
+	expires time.Time
+}
+
+// Unfurler fetches and caches previews. Connections to private and reserved IP ranges are refused,
+// and the fetched documents are limited in size and time.
+type Unfurler struct {
+	sync.Mutex
+	cl         *http.Client
+	maxBytes   int64
+	ttl        time.Duration
+	maxEntries int
+	cache      map[string]cacheEntry
+	flights    cache.Group
+}
+
+// New creates a new unfurler.
+func New() *Unfurler {
+	dialer := &net.Dialer{Timeout: defaultTimeout, Control: netguard.Control}
+	tr := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   defaultTimeout,
+		ResponseHeaderTimeout: defaultTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	cl := &http.Client{
+		Transport: tr,
+		Timeout:   defaultTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrInvalidURL
+			}
+			return nil
+		},
+	}
+	return &Unfurler{
+		cl:         cl,
+		maxBytes:   defaultMaxBytes,
+		ttl:        defaultTTL,
+		maxEntries: defaultMaxEntries,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Unfurl returns the preview of the URL from the cache, or fetches it.
+// Failures are cached for a short time, so that bad URLs are not fetched repeatedly, unless the fetch
+// was cancelled or timed out by its context.
+func (u *Unfurler) Unfurl(ctx context.Context, rawURL string) (Preview, error) {
+	target, err := parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	key := target.String()
+
+	u.Lock()
+	e, ok := u.cache[key]
+	u.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.preview, e.err
+	}
+
+	// concurrent requests for the same URL share one fetch.
+	v, _, err := u.flights.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		p, err := u.fetch(ctx, target)
+		if err != nil && ctx.Err() != nil {
+			return p, err
+		}
+		ttl := u.ttl
+		if err != nil {
+			ttl = errorTTL
+		}
+
+		u.Lock()
+		defer u.Unlock()
+		if len(u.cache) >= u.maxEntries {
+			u.evict()
+		}
+		u.cache[key] = cacheEntry{preview: p, err: err, expires: time.Now().Add(ttl)}
+		return p, err
+	})
+	p, _ := v.(Preview)
+	return p, err
+}
+
+func parse(rawURL string) (*url.URL, error) {
+	t, err := url.Parse(rawURL)
+	if err != nil || (t.Scheme != "http" && t.Scheme != "https") || t.Host == "" || t.User != nil {
+		return nil, ErrInvalidURL
+	}
+	t.Fragment = ""
+	return t, nil
+}
+
+func (u *Unfurler) fetch(ctx context.Context, target *url.URL) (Preview, error) {
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Preview{}, ErrInvalidURL
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "RealWorldUnfurl/1.0")
+	rsp, err := u.cl.Do(req.WithContext(ctx))
+	if err != nil {
+		return Preview{}, errors.Wrap(err, "failed to fetch url")
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return Preview{}, errors.Errorf("url returned status %d", rsp.StatusCode)
+	}
+	if mt, _, err := mime.ParseMediaType(rsp.Header.Get("Content-Type")); err != nil || mt != "text/html" {
+		return Preview{}, errors.New("url is not an html document")
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(rsp.Body, u.maxBytes))
+	if err != nil {
+		return Preview{}, errors.Wrap(err, "failed to read document")
+	}
+	return extract(rsp.Request.URL, string(b)), nil
+}
+
+// extract reads the OpenGraph and Twitter card metadata, falling back to the title and description of the document.
+func extract(base *url.URL, doc string) Preview {
+	meta := make(map[string]string)
+	for _, tag := range metaTag.FindAllString(doc, -1) {
+		var key, content string
+		for _, m := range attr.FindAllStringSubmatch(tag, -1) {
+			v := strings.Trim(m[2], `"'`)
+			switch strings.ToLower(m[1]) {
+			case "content":
+				content = v
+			default:
+				key = strings.ToLower(v)
+			}
+		}
+		if key != "" && content != "" {
+			if _, ok := meta[key]; !ok {
+				meta[key] = html.UnescapeString(strings.TrimSpace(content))
+			}
+		}
+	}
+
+	p := Preview{
+		URL:         first(meta["og:url"], base.String()),
+		Title:       first(meta["og:title"], meta["twitter:title"]),
+		Description: first(meta["og:description"], meta["twitter:description"], meta["description"]),
+		Image:       first(meta["og:image"], meta["twitter:image"]),
+		SiteName:    first(meta["og:site_name"], base.Hostname()),
+	}
+	if p.Title == "" {
+		if m := titleTag.FindStringSubmatch(doc); m != nil {
+			p.Title = html.UnescapeString(strings.TrimSpace(m[1]))
+		}
+	}
+	if p.Image != "" {
+		if img, err := base.Parse(p.Image); err == nil && (img.Scheme == "http" || img.Scheme == "https") {
+			p.Image = img.String()
+		} else {
+			p.Image = ""
+		}
+	}
+	return p
+}
+
+func first(vv ...string) string {
+	for _, v := range vv {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (u *Unfurler) evict() {
+	now := time.Now()
+	for k, e := range u.cache {
+		if !now.Before(e.expires) {
+			delete(u.cache, k)
+		}
+	}
+	for k := range u.cache {
+		if len(u.cache) < u.maxEntries {
+			return
+		}
+		delete(u.cache, k)
+	}
+}
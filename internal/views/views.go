@@ -0,0 +1,194 @@
+// Package views counts article views. Views are deduplicated per viewer within a window
+// and buffered in memory, and the increments are written to the store in batches.
+package views
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultWindow        = 30 * time.Minute
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxPending    = 1000
+)
+
+var viewCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "views",
+	Name:      "recorded_total",
+	Help:      "Number of article views, by whether they were counted or deduplicated.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(viewCounter)
+}
+
+// Sink interface for persisting view increments per article.
+type Sink interface {
+	IncrementViews(ctx context.Context, counts map[string]int64) error
+}
+
+// OptionFunc definition for configuring the counter in a functional way.
+type OptionFunc func(*Counter) error
+
+// Window option for setting the period within which repeated views of a viewer are counted once.
+func Window(d time.Duration) OptionFunc {
+	return func(c *Counter) error {
+		if d <= 0 {
+			return errors.New("window must be positive")
+		}
+		c.window = d
+		return nil
+	}
+}
+
+// FlushInterval option for setting how often the buffered increments are written.
+func FlushInterval(d time.Duration) OptionFunc {
+	return func(c *Counter) error {
+		if d <= 0 {
+			return errors.New("flush interval must be positive")
+		}
+		c.flushInterval = d
+		return nil
+	}
+}
+
+// MaxPending option for setting the number of buffered articles that triggers an early flush.
+func MaxPending(n int) OptionFunc {
+	return func(c *Counter) error {
+		if n <= 0 {
+			return errors.New("max pending must be positive")
+		}
+		c.maxPending = n
+		return nil
+	}
+}
+
+// Counter records views and writes them asynchronously to the sink. It implements the patron Component interface.
+type Counter struct {
+	sync.Mutex
+	sink          Sink
+	window        time.Duration
+	flushInterval time.Duration
+	maxPending    int
+	seen          map[string]time.Time
+	pending       map[string]int64
+	chFlush       chan struct{}
+}
+
+// New creates a new view counter.
+func New(s Sink, oo ...OptionFunc) (*Counter, error) {
+	if s == nil {
+		return nil, errors.New("sink is nil")
+	}
+	c := Counter{
+		sink:          s,
+		window:        defaultWindow,
+		flushInterval: defaultFlushInterval,
+		maxPending:    defaultMaxPending,
+		seen:          make(map[string]time.Time),
+		pending:       make(map[string]int64),
+		chFlush:       make(chan struct{}, 1),
+	}
+	for _, o := range oo {
+		err := o(&c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Record a view of the article by the viewer, which is the user ID or the IP of anonymous readers.
+// It returns false if the view has been deduplicated.
+func (c *Counter) Record(articleID, viewer string) bool {
+	key := articleID + "|" + viewer
+	now := time.Now()
+
+	c.Lock()
+	defer c.Unlock()
+
+	if at, ok := c.seen[key]; ok && now.Sub(at) < c.window {
+		viewCounter.WithLabelValues("deduplicated").Inc()
+		return false
+	}
+	c.seen[key] = now
+	c.pending[articleID]++
+	viewCounter.WithLabelValues("counted").Inc()
+
+	if len(c.pending) >= c.maxPending {
+		select {
+		case c.chFlush <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Run flushes the buffered views periodically until the context is cancelled, then flushes one last time.
+func (c *Counter) Run(ctx context.Context) error {
+	tc := time.NewTicker(c.flushInterval)
+	defer tc.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return c.flush(context.Background())
+		case <-tc.C:
+			c.logFlush(ctx)
+		case <-c.chFlush:
+			c.logFlush(ctx)
+		}
+	}
+}
+
+// Info returns information of the component.
+func (c *Counter) Info() map[string]interface{} {
+	return map[string]interface{}{
+		"type":           "views",
+		"window":         c.window.String(),
+		"flush-interval": c.flushInterval.String(),
+		"max-pending":    c.maxPending,
+	}
+}
+
+func (c *Counter) logFlush(ctx context.Context) {
+	if err := c.flush(ctx); err != nil {
+		log.Errorf("failed to flush views: %v", err)
+	}
+}
+
+func (c *Counter) flush(ctx context.Context) error {
+	c.Lock()
+	now := time.Now()
+	for k, at := range c.seen {
+		if now.Sub(at) >= c.window {
+			delete(c.seen, k)
+		}
+	}
+	if len(c.pending) == 0 {
+		c.Unlock()
+		return nil
+	}
+	counts := c.pending
+	c.pending = make(map[string]int64)
+	c.Unlock()
+
+	err := c.sink.IncrementViews(ctx, counts)
+	if err == nil {
+		return nil
+	}
+
+	// keep the increments for the next flush.
+	c.Lock()
+	for id, n := range counts {
+		c.pending[id] += n
+	}
+	c.Unlock()
+	return errors.Wrap(err, "failed to increment views")
+}
@@ -0,0 +1,305 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/netguard"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultMaxAttempts  = 8
+	defaultBaseBackoff  = 10 * time.Second
+	defaultMaxBackoff   = time.Hour
+	defaultTimeout      = 10 * time.Second
+	defaultConcurrency  = 10
+	batchSize           = 100
+)
+
+var deliveryCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "realworld",
+	Subsystem: "webhook",
+	Name:      "delivery_attempts_total",
+	Help:      "Number of webhook delivery attempts, by event and result.",
+}, []string{"event", "result"})
+
+func init() {
+	prometheus.MustRegister(deliveryCounter)
+}
+
+// OptionFunc definition for configuring the dispatcher in a functional way.
+type OptionFunc func(*Dispatcher) error
+
+// PollInterval option for setting how often due deliveries are looked up.
+func PollInterval(d time.Duration) OptionFunc {
+	return func(ds *Dispatcher) error {
+		if d <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+		ds.pollInterval = d
+		return nil
+	}
+}
+
+// Retries option for setting the maximum attempts of a delivery and the backoff between them,
+// which doubles after every attempt starting from base, up to max.
+func Retries(attempts int, base, max time.Duration) OptionFunc {
+	return func(ds *Dispatcher) error {
+		if attempts <= 0 {
+			return errors.New("attempts must be positive")
+		}
+		if base <= 0 || max < base {
+			return errors.New("base backoff must be positive and not greater than max backoff")
+		}
+		ds.maxAttempts = attempts
+		ds.baseBackoff = base
+		ds.maxBackoff = max
+		return nil
+	}
+}
+
+// Timeout option for setting the timeout of a delivery request.
+func Timeout(d time.Duration) OptionFunc {
+	return func(ds *Dispatcher) error {
+		if d <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		ds.cl.Timeout = d
+		return nil
+	}
+}
+
+// Concurrency option for setting the maximum number of deliveries sent at the same time,
+// so that slow receivers don't hold back the deliveries to the others.
+func Concurrency(n int) OptionFunc {
+	return func(ds *Dispatcher) error {
+		if n <= 0 {
+			return errors.New("concurrency must be positive")
+		}
+		ds.concurrency = n
+		return nil
+	}
+}
+
+// Dispatcher enqueues the events for the subscriptions and delivers them in the background.
+// The receivers are reached only on public addresses, so that webhooks can't be used to call internal services.
+// It implements the patron Component interface.
+type Dispatcher struct {
+	store        Store
+	cl           *http.Client
+	concurrency  int
+	pollInterval time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+}
+
+// NewDispatcher creates a new dispatcher.
+func NewDispatcher(s Store, oo ...OptionFunc) (*Dispatcher, error) {
+	if s == nil {
+		return nil, errors.New("store is nil")
+	}
+	dialer := &net.Dialer{Timeout: defaultTimeout, Control: netguard.Control}
+	tr := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   defaultTimeout,
+		ResponseHeaderTimeout: defaultTimeout,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	ds := Dispatcher{
+		store:        s,
+		cl:           &http.Client{Transport: tr, Timeout: defaultTimeout},
+		concurrency:  defaultConcurrency,
+		pollInterval: defaultPollInterval,
+		maxAttempts:  defaultMaxAttempts,
+		baseBackoff:  defaultBaseBackoff,
+		maxBackoff:   defaultMaxBackoff,
+	}
+	for _, o := range oo {
+		err := o(&ds)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ds, nil
+}
+
+// Publish enqueues a delivery of the event for every subscription to it.
+// The payload is encoded to JSON once, so that all receivers get the same body.
+func (ds *Dispatcher) Publish(ctx context.Context, event string, payload interface{}) error {
+	ss, err := ds.store.SubscriptionsFor(ctx, event)
+	if err != nil {
+		return errors.Wrap(err, "failed to get subscriptions")
+	}
+	if len(ss) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode payload")
+	}
+	now := time.Now().UTC()
+	dd := make([]Delivery, 0, len(ss))
+	for _, s := range ss {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		dd = append(dd, Delivery{
+			ID:             id,
+			SubscriptionID: s.ID,
+			Event:          event,
+			Payload:        b,
+			Status:         StatusPending,
+			NextAttempt:    now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+	return ds.store.CreateDeliveries(ctx, dd)
+}
+
+// Run delivers the due deliveries periodically until the context is cancelled.
+func (ds *Dispatcher) Run(ctx context.Context) error {
+	tc := time.NewTicker(ds.pollInterval)
+	defer tc.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tc.C:
+			if err := ds.deliverDue(ctx); err != nil {
+				log.Errorf("failed to deliver webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// Info returns information of the component.
+func (ds *Dispatcher) Info() map[string]interface{} {
+	return map[string]interface{}{
+		"type":          "webhook-dispatcher",
+		"poll-interval": ds.pollInterval.String(),
+		"max-attempts":  ds.maxAttempts,
+		"base-backoff":  ds.baseBackoff.String(),
+		"max-backoff":   ds.maxBackoff.String(),
+		"timeout":       ds.cl.Timeout.String(),
+		"concurrency":   ds.concurrency,
+	}
+}
+
+func (ds *Dispatcher) deliverDue(ctx context.Context) error {
+	dd, err := ds.store.DueDeliveries(ctx, time.Now(), batchSize)
+	if err != nil {
+		return errors.Wrap(err, "failed to get due deliveries")
+	}
+	ch := make(chan Delivery)
+	var wg sync.WaitGroup
+	for i := 0; i < ds.concurrency && i < len(dd); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range ch {
+				ds.deliver(ctx, d)
+			}
+		}()
+	}
+	for _, d := range dd {
+		if ctx.Err() != nil {
+			break
+		}
+		ch <- d
+	}
+	close(ch)
+	wg.Wait()
+	return nil
+}
+
+func (ds *Dispatcher) deliver(ctx context.Context, d Delivery) {
+	s, err := ds.store.GetSubscription(ctx, d.SubscriptionID)
+	if err == ErrNotFound {
+		// the subscription has been deleted since the event was published.
+		d.Status = StatusFailed
+		d.LastError = "subscription deleted"
+		ds.update(ctx, d)
+		return
+	}
+	if err != nil {
+		log.Errorf("failed to get subscription %s of delivery %s: %v", d.SubscriptionID, d.ID, err)
+		return
+	}
+
+	d.Attempts++
+	code, err := ds.send(ctx, s, d)
+	d.ResponseCode = code
+	now := time.Now().UTC()
+	d.UpdatedAt = now
+	switch {
+	case err == nil:
+		d.Status = StatusSucceeded
+		d.LastError = ""
+		deliveryCounter.WithLabelValues(d.Event, "success").Inc()
+	case d.Attempts >= ds.maxAttempts:
+		d.Status = StatusFailed
+		d.LastError = err.Error()
+		deliveryCounter.WithLabelValues(d.Event, "failed").Inc()
+		log.Warnf("webhook delivery %s to %s failed after %d attempts: %v", d.ID, s.URL, d.Attempts, err)
+	default:
+		d.LastError = err.Error()
+		d.NextAttempt = now.Add(ds.backoff(d.Attempts))
+		deliveryCounter.WithLabelValues(d.Event, "retry").Inc()
+	}
+	ds.update(ctx, d)
+}
+
+func (ds *Dispatcher) send(ctx context.Context, s Subscription, d Delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, time.Now(), d.Payload))
+
+	rsp, err := ds.cl.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(rsp.Body, 64<<10))
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, errors.Errorf("receiver returned status %d", rsp.StatusCode)
+	}
+	return rsp.StatusCode, nil
+}
+
+func (ds *Dispatcher) backoff(attempts int) time.Duration {
+	d := ds.baseBackoff
+	for i := 1; i < attempts && d < ds.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > ds.maxBackoff {
+		return ds.maxBackoff
+	}
+	return d
+}
+
+func (ds *Dispatcher) update(ctx context.Context, d Delivery) {
+	if err := ds.store.UpdateDelivery(ctx, d); err != nil {
+		log.Errorf("failed to update webhook delivery %s: %v", d.ID, err)
+	}
+}
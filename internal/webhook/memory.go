@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+// MemoryStore is an in-memory implementation of the Store.
+type MemoryStore struct {
+	sync.RWMutex
+	subscriptions map[string]Subscription
+	deliveries    map[string]Delivery
+}
+
+// NewMemoryStore creates a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subscriptions: make(map[string]Subscription), deliveries: make(map[string]Delivery)}
+}
+
+// CreateSubscription stores a subscription.
+func (s *MemoryStore) CreateSubscription(_ context.Context, sub Subscription) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.subscriptions[sub.ID]; ok {
+		return errors.Errorf("webhook %s already exists", sub.ID)
+	}
+	s.subscriptions[sub.ID] = sub
+	return nil
+}
+
+// ListSubscriptions returns the subscriptions of the owner, oldest first.
+func (s *MemoryStore) ListSubscriptions(_ context.Context, owner string) ([]Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var ss []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.Owner == owner {
+			ss = append(ss, sub)
+		}
+	}
+	sort.Slice(ss, func(i, j int) bool { return ss[i].CreatedAt.Before(ss[j].CreatedAt) })
+	return ss, nil
+}
+
+// DeleteSubscription deletes a subscription of the owner.
+func (s *MemoryStore) DeleteSubscription(_ context.Context, owner, id string) error {
+	s.Lock()
+	defer s.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.Owner != owner {
+		return ErrNotFound
+	}
+	delete(s.subscriptions, id)
+	return nil
+}
+
+// GetSubscription returns a subscription.
+func (s *MemoryStore) GetSubscription(_ context.Context, id string) (Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return Subscription{}, ErrNotFound
+	}
+	return sub, nil
+}
+
+// SubscriptionsFor returns the subscriptions to the event.
+func (s *MemoryStore) SubscriptionsFor(_ context.Context, event string) ([]Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var ss []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.Subscribed(event) {
+			ss = append(ss, sub)
+		}
+	}
+	return ss, nil
+}
+
+// CreateDeliveries stores deliveries.
+func (s *MemoryStore) CreateDeliveries(_ context.Context, dd []Delivery) error {
+	s.Lock()
+	defer s.Unlock()
+	for _, d := range dd {
+		s.deliveries[d.ID] = d
+	}
+	return nil
+}
+
+// DueDeliveries returns up to limit pending deliveries whose next attempt is due, oldest first.
+func (s *MemoryStore) DueDeliveries(_ context.Context, now time.Time, limit int) ([]Delivery, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var dd []Delivery
+	for _, d := range s.deliveries {
+		if d.Status == StatusPending && !d.NextAttempt.After(now) {
+			dd = append(dd, d)
+		}
+	}
+	sort.Slice(dd, func(i, j int) bool { return dd[i].NextAttempt.Before(dd[j].NextAttempt) })
+	if len(dd) > limit {
+		dd = dd[:limit]
+	}
+	return dd, nil
+}
+
+// UpdateDelivery replaces a delivery.
+func (s *MemoryStore) UpdateDelivery(_ context.Context, d Delivery) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return errors.Errorf("delivery %s not found", d.ID)
+	}
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+// ListDeliveries returns up to limit deliveries of the subscription, newest first.
+func (s *MemoryStore) ListDeliveries(_ context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var dd []Delivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			dd = append(dd, d)
+		}
+	}
+	sort.Slice(dd, func(i, j int) bool { return dd[i].CreatedAt.After(dd[j].CreatedAt) })
+	if len(dd) > limit {
+		dd = dd[:limit]
+	}
+	return dd, nil
+}
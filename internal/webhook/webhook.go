@@ -0,0 +1,164 @@
+// Package webhook delivers content events to the webhook URLs registered by users and integrations.
+// Deliveries are signed with the secret of the subscription and retried with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/errors"
+)
+
+const (
+	// ArticlePublished is the event of a published article.
+	ArticlePublished = "article.published"
+	// CommentCreated is the event of a created comment.
+	CommentCreated = "comment.created"
+
+	// SignatureHeader is the header of the signature of a delivery, in the form t=<unix time>,v1=<hex hmac>.
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader is the header of the event of a delivery.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader is the header of the ID of a delivery, which receivers can use to deduplicate retries.
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// Events are the events that can be subscribed to.
+var Events = []string{ArticlePublished, CommentCreated}
+
+// ErrNotFound is returned when a subscription does not exist.
+var ErrNotFound = errors.New("webhook not found")
+
+// Subscription of a URL to events.
+type Subscription struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Subscribed returns true if the subscription receives the event.
+func (s Subscription) Subscribed(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Status of a delivery.
+type Status string
+
+const (
+	// StatusPending deliveries are waiting for their next attempt.
+	StatusPending Status = "pending"
+	// StatusSucceeded deliveries have been accepted by the receiver.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed deliveries have exhausted their attempts.
+	StatusFailed Status = "failed"
+)
+
+// Delivery of an event to a subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscriptionId"`
+	Event          string    `json:"event"`
+	Payload        []byte    `json:"-"`
+	Status         Status    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	ResponseCode   int       `json:"responseCode,omitempty"`
+	LastError      string    `json:"lastError,omitempty"`
+	NextAttempt    time.Time `json:"nextAttempt"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Store interface for persisting subscriptions and deliveries.
+type Store interface {
+	CreateSubscription(ctx context.Context, s Subscription) error
+	ListSubscriptions(ctx context.Context, owner string) ([]Subscription, error)
+	DeleteSubscription(ctx context.Context, owner, id string) error
+	GetSubscription(ctx context.Context, id string) (Subscription, error)
+	SubscriptionsFor(ctx context.Context, event string) ([]Subscription, error)
+	CreateDeliveries(ctx context.Context, dd []Delivery) error
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, d Delivery) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+}
+
+// NewSubscription validates and creates a new subscription with a random secret,
+// which has to be shown to the owner once so that they can verify the signatures.
+func NewSubscription(owner, rawURL string, events []string) (Subscription, error) {
+	if owner == "" {
+		return Subscription{}, errors.New("owner is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return Subscription{}, errors.Errorf("invalid webhook url %q", rawURL)
+	}
+	if len(events) == 0 {
+		return Subscription{}, errors.New("events are required")
+	}
+	for _, e := range events {
+		if !known(e) {
+			return Subscription{}, errors.Errorf("unknown event %q", e)
+		}
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Subscription{}, errors.Wrap(err, "failed to generate secret")
+	}
+	id, err := newID()
+	if err != nil {
+		return Subscription{}, err
+	}
+	return Subscription{
+		ID:        id,
+		Owner:     owner,
+		URL:       u.String(),
+		Secret:    "whsec_" + hex.EncodeToString(b),
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate id")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func known(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature header value of the payload sent at the time.
+// The signature is the HMAC-SHA256 of "<unix time>.<payload>", so that receivers can reject replays.
+func Sign(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, payload)
+}
+
+func signature(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(ts))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,212 @@
+// Package wordfilter rejects or masks inappropriate words in user provided text such as usernames,
+// bios and titles, using dictionaries per locale.
+package wordfilter
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/apierror"
+	"github.com/georgegg/go-patron-realworld-example-app/internal/textnorm"
+)
+
+// DefaultLocale is the locale of the dictionary applied to text of every locale.
+const DefaultLocale = "default"
+
+var word = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Filter holds the dictionaries per locale. Entries are words or phrases, compared after normalization,
+// so that e.g. accents, case or the spacing of a phrase do not bypass the filter.
+type Filter struct {
+	sync.RWMutex
+	dicts map[string]map[string]bool
+	// maxWords is the number of words of the longest entry.
+	maxWords int
+}
+
+// New creates a new filter with empty dictionaries.
+func New() *Filter {
+	return &Filter{dicts: make(map[string]map[string]bool)}
+}
+
+// LoadDir loads the dictionaries from the <locale>.txt files of the directory, e.g. default.txt and de.txt.
+// Files contain one word or phrase per line; empty lines and lines starting with # are ignored.
+func (f *Filter) LoadDir(dir string) error {
+	ff, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list dictionaries")
+	}
+	for _, file := range ff {
+		ww, err := readWords(file)
+		if err != nil {
+			return err
+		}
+		locale := strings.TrimSuffix(filepath.Base(file), ".txt")
+		f.Set(locale, ww)
+		log.Infof("loaded %d words for locale %s", len(ww), locale)
+	}
+	return nil
+}
+
+// Set replaces the dictionary of the locale.
+func (f *Filter) Set(locale string, ww []string) {
+	d := make(map[string]bool, len(ww))
+	for _, w := range ww {
+		if n := normalizePhrase(w, locale); n != "" {
+			d[n] = true
+		}
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.dicts[language(locale)] = d
+	f.maxWords = 0
+	for _, d := range f.dicts {
+		for n := range d {
+			if c := strings.Count(n, " ") + 1; c > f.maxWords {
+				f.maxWords = c
+			}
+		}
+	}
+}
+
+// Words returns the normalized entries of the dictionary of the locale, sorted.
+func (f *Filter) Words(locale string) []string {
+	f.RLock()
+	defer f.RUnlock()
+	d := f.dicts[language(locale)]
+	ww := make([]string, 0, len(d))
+	for w := range d {
+		ww = append(ww, w)
+	}
+	sort.Strings(ww)
+	return ww
+}
+
+// Check returns a 422 validation error if the text of the field contains an entry of the dictionaries
+// of the locale or of the default dictionary.
+func (f *Filter) Check(field, text, locale string) error {
+	f.RLock()
+	defer f.RUnlock()
+	ww := words(text, locale)
+	for i := range ww {
+		if f.match(ww[i:], locale) > 0 {
+			return apierror.Validation(field + " contains inappropriate language")
+		}
+	}
+	return nil
+}
+
+// Mask replaces the letters of the filtered words and phrases of the text with asterisks.
+func (f *Filter) Mask(text, locale string) string {
+	f.RLock()
+	defer f.RUnlock()
+	ww := words(text, locale)
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(ww); {
+		n := f.match(ww[i:], locale)
+		if n == 0 {
+			i++
+			continue
+		}
+		for _, w := range ww[i : i+n] {
+			b.WriteString(text[last:w.start])
+			b.WriteString(strings.Repeat("*", len([]rune(text[w.start:w.end]))))
+			last = w.end
+		}
+		i += n
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// textWord is a word of a text, with its normalized form and its byte offsets.
+type textWord struct {
+	norm       string
+	start, end int
+}
+
+func words(text, locale string) []textWord {
+	var ww []textWord
+	for _, loc := range word.FindAllStringIndex(text, -1) {
+		if n := normalize(text[loc[0]:loc[1]], locale); n != "" {
+			ww = append(ww, textWord{norm: n, start: loc[0], end: loc[1]})
+		}
+	}
+	return ww
+}
+
+// match returns the number of words of the longest entry the words start with, or 0 if there is none.
+func (f *Filter) match(ww []textWord, locale string) int {
+	max := f.maxWords
+	if len(ww) < max {
+		max = len(ww)
+	}
+	var b strings.Builder
+	found := 0
+	for n := 1; n <= max; n++ {
+		if n > 1 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(ww[n-1].norm)
+		p := b.String()
+		if f.dicts[DefaultLocale][p] || f.dicts[language(locale)][p] {
+			found = n
+		}
+	}
+	return found
+}
+
+func normalize(w, locale string) string {
+	return strings.Join(textnorm.Terms(w, locale), "")
+}
+
+// normalizePhrase normalizes every word of a dictionary entry, joining them with single spaces.
+func normalizePhrase(p, locale string) string {
+	var nn []string
+	for _, w := range word.FindAllString(p, -1) {
+		if n := normalize(w, locale); n != "" {
+			nn = append(nn, n)
+		}
+	}
+	return strings.Join(nn, " ")
+}
+
+// language returns the language of a locale, e.g. de for de-AT, which is the key of its dictionary.
+func language(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" {
+		return DefaultLocale
+	}
+	return strings.ToLower(locale)
+}
+
+func readWords(file string) ([]string, error) {
+	fl, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open dictionary %s", file)
+	}
+	defer fl.Close()
+	var ww []string
+	sc := bufio.NewScanner(fl)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		ww = append(ww, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read dictionary %s", file)
+	}
+	return ww, nil
+}